@@ -0,0 +1,54 @@
+package httperr
+
+// The following interfaces are implemented by all errors created by this package (but are not part of HttpError)
+// and allow status specific data to be read using errors.As - for example...
+//
+//	var le httperr.LocationError
+//	if errors.As(err, &le) {
+//		if location, ok := le.Location(); ok {
+//			...
+//		}
+//	}
+
+// LocationError is an error that may have a redirect location (e.g. 3xx errors)
+type LocationError interface {
+	error
+	// Location returns the redirect location (i.e. the Location header) - if any
+	Location() (string, bool)
+}
+
+// RetryAfterError is an error that may have a Retry-After (e.g. 429 and 503 errors)
+type RetryAfterError interface {
+	error
+	// RetryAfter returns the Retry-After - if any
+	RetryAfter() (RetryAfter, bool)
+}
+
+// AllowError is an error that may have allowed methods (e.g. 405 errors)
+type AllowError interface {
+	error
+	// Allow returns the allowed methods (i.e. the Allow header)
+	Allow() []string
+}
+
+// ChallengeError is an error that may have authentication challenges (e.g. 401 and 407 errors)
+type ChallengeError interface {
+	error
+	// Challenges returns the authentication challenges (i.e. the WWW-Authenticate or Proxy-Authenticate headers)
+	Challenges() []Challenge
+}
+
+// ContentRangeError is an error that may have a content range (e.g. 416 errors)
+type ContentRangeError interface {
+	error
+	// ContentRange returns the content range (i.e. the Content-Range header) - if any
+	ContentRange() (string, bool)
+}
+
+var _ LocationError = (*httpError)(nil)
+var _ RetryAfterError = (*httpError)(nil)
+var _ AllowError = (*httpError)(nil)
+var _ ChallengeError = (*httpError)(nil)
+var _ ContentRangeError = (*httpError)(nil)
+
+const hdrContentRange = "Content-Range"
@@ -0,0 +1,62 @@
+package httperr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAccessorInterfaces(t *testing.T) {
+	t.Run("LocationError", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", NewFoundError("", "/home"))
+		var le LocationError
+		require.True(t, errors.As(err, &le))
+		location, ok := le.Location()
+		require.True(t, ok)
+		require.Equal(t, "/home", location)
+
+		require.True(t, errors.As(NewNotFoundError(""), &le))
+		_, ok = le.Location()
+		require.False(t, ok)
+	})
+	t.Run("RetryAfterError", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", NewTooManyRequestsRetryError("", time.Minute))
+		var re RetryAfterError
+		require.True(t, errors.As(err, &re))
+		ra, ok := re.RetryAfter()
+		require.True(t, ok)
+		require.Equal(t, time.Minute, ra.Delay)
+	})
+	t.Run("AllowError", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", NewMethodNotAllowedAllowError("", http.MethodGet))
+		var ae AllowError
+		require.True(t, errors.As(err, &ae))
+		require.Equal(t, []string{http.MethodGet}, ae.Allow())
+	})
+	t.Run("ChallengeError", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", NewUnauthorizedChallengeError("", BearerChallenge("api")))
+		var ce ChallengeError
+		require.True(t, errors.As(err, &ce))
+		require.Len(t, ce.Challenges(), 1)
+		require.Equal(t, "Bearer", ce.Challenges()[0].Scheme)
+	})
+	t.Run("ContentRangeError", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", NewRequestedRangeNotSatisfiableError("").AddHeader("Content-Range", "bytes */100"))
+		var cre ContentRangeError
+		require.True(t, errors.As(err, &cre))
+		cr, ok := cre.ContentRange()
+		require.True(t, ok)
+		require.Equal(t, "bytes */100", cr)
+
+		require.True(t, errors.As(NewNotFoundError(""), &cre))
+		_, ok = cre.ContentRange()
+		require.False(t, ok)
+	})
+	t.Run("plain error", func(t *testing.T) {
+		var le LocationError
+		require.False(t, errors.As(errors.New("plain"), &le))
+	})
+}
@@ -0,0 +1,105 @@
+package httperr
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheRule determines the caching headers written for an error response
+type CacheRule struct {
+	// CacheControl is the Cache-Control header value (e.g. "no-store", "public")
+	//
+	// if MaxAge is also set, a max-age directive is appended
+	CacheControl string
+	// MaxAge is the max-age for the response - when set, an Expires header is also written
+	MaxAge time.Duration
+	// Vary are the header names added to the Vary header (e.g. "Authorization")
+	Vary []string
+}
+
+// CachePolicy determines the caching headers (Cache-Control, Expires and Vary) written for error responses
+//
+// if an error sets its own Cache-Control header, the Cache-Control and Expires from the policy are not written
+// (but the policy Vary header names are still added)
+type CachePolicy struct {
+	// Statuses are the cache rules by status code
+	Statuses map[int]CacheRule
+	// Classes are the cache rules by status class (e.g. 4 for 4xx, 5 for 5xx) - used when
+	// there is no rule for the specific status code
+	Classes map[int]CacheRule
+}
+
+// DefaultCachePolicy is the cache policy applied when writing errors
+//
+// by default, 5xx errors are not cached (unless the error sets its own Cache-Control header) and 401/403 errors
+// vary by Authorization - for example,
+// to allow 404 and 410 errors to be cached briefly...
+//
+//	httperr.DefaultCachePolicy.Statuses[http.StatusNotFound] = httperr.CacheRule{CacheControl: "public", MaxAge: time.Minute}
+//	httperr.DefaultCachePolicy.Statuses[http.StatusGone] = httperr.CacheRule{CacheControl: "public", MaxAge: time.Hour}
+//
+// if this is set to nil, no caching headers are written (other than those set by the error)
+var DefaultCachePolicy = &CachePolicy{
+	Statuses: map[int]CacheRule{
+		http.StatusUnauthorized: {Vary: []string{hdrAuthorization}},
+		http.StatusForbidden:    {Vary: []string{hdrAuthorization}},
+	},
+	Classes: map[int]CacheRule{
+		5: {CacheControl: "no-store"},
+	},
+}
+
+const (
+	hdrAuthorization = "Authorization"
+	hdrCacheControl  = "Cache-Control"
+	hdrExpires       = "Expires"
+	hdrVary          = "Vary"
+)
+
+// Rule returns the cache rule for the status code (if any)
+func (cp *CachePolicy) Rule(status int) (CacheRule, bool) {
+	if cp == nil {
+		return CacheRule{}, false
+	}
+	if rule, ok := cp.Statuses[status]; ok {
+		return rule, true
+	}
+	rule, ok := cp.Classes[status/100]
+	return rule, ok
+}
+
+func (cp *CachePolicy) apply(hdrs http.Header, status int, errorHdrs http.Header) {
+	rule, ok := cp.Rule(status)
+	if !ok {
+		return
+	}
+	if errorHdrs.Get(hdrCacheControl) == "" {
+		cc := rule.CacheControl
+		if rule.MaxAge > 0 {
+			if cc != "" {
+				cc += ", "
+			}
+			cc += "max-age=" + strconv.FormatInt(int64(rule.MaxAge/time.Second), 10)
+			hdrs.Set(hdrExpires, timeNow().Add(rule.MaxAge).UTC().Format(http.TimeFormat))
+		}
+		if cc != "" {
+			hdrs.Set(hdrCacheControl, cc)
+		}
+	}
+	for _, v := range rule.Vary {
+		addVary(hdrs, v)
+	}
+}
+
+func addVary(hdrs http.Header, name string) {
+	for _, existing := range hdrs.Values(hdrVary) {
+		for _, token := range strings.Split(existing, ",") {
+			if token = strings.TrimSpace(token); token == "*" || strings.EqualFold(token, name) {
+				return
+			}
+		}
+	}
+	hdrs.Add(hdrVary, name)
+}
@@ -0,0 +1,87 @@
+package httperr
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCachePolicy_Rule(t *testing.T) {
+	cp := &CachePolicy{
+		Statuses: map[int]CacheRule{http.StatusNotFound: {CacheControl: "public"}},
+		Classes:  map[int]CacheRule{4: {CacheControl: "private"}},
+	}
+	rule, ok := cp.Rule(http.StatusNotFound)
+	require.True(t, ok)
+	require.Equal(t, "public", rule.CacheControl)
+	rule, ok = cp.Rule(http.StatusConflict)
+	require.True(t, ok)
+	require.Equal(t, "private", rule.CacheControl)
+	_, ok = cp.Rule(http.StatusInternalServerError)
+	require.False(t, ok)
+	var nilPolicy *CachePolicy
+	_, ok = nilPolicy.Rule(http.StatusNotFound)
+	require.False(t, ok)
+}
+
+func TestDefaultErrorWriter_CachePolicy(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	timeNow = func() time.Time {
+		return now
+	}
+	defer func(cp *CachePolicy) {
+		DefaultCachePolicy = cp
+		timeNow = time.Now
+	}(DefaultCachePolicy)
+	t.Run("5xx", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewBadGatewayError("", nil), w)
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		require.Empty(t, w.Header().Get("Expires"))
+	})
+	t.Run("401", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		w.Header().Set("Vary", "Accept, authorization")
+		DefaultErrorWriter.WriteError(NewUnauthorizedError(""), w)
+		require.Equal(t, []string{"Accept, authorization"}, w.Header().Values("Vary"))
+		require.Empty(t, w.Header().Get("Cache-Control"))
+	})
+	t.Run("403", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewForbiddenError("").AddHeader("Cache-Control", "private"), w)
+		require.Equal(t, []string{"Authorization"}, w.Header().Values("Vary"))
+		require.Equal(t, "private", w.Header().Get("Cache-Control"))
+	})
+	t.Run("no rule", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewNotFoundError(""), w)
+		require.Empty(t, w.Header().Get("Cache-Control"))
+		require.Empty(t, w.Header().Get("Vary"))
+	})
+	t.Run("configured", func(t *testing.T) {
+		DefaultCachePolicy = &CachePolicy{
+			Statuses: map[int]CacheRule{
+				http.StatusNotFound: {CacheControl: "public", MaxAge: time.Minute, Vary: []string{"Accept"}},
+				http.StatusGone:     {MaxAge: time.Hour},
+			},
+		}
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewNotFoundError(""), w)
+		require.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+		require.Equal(t, "Thu, 02 Jan 2025 03:05:05 GMT", w.Header().Get("Expires"))
+		require.Equal(t, "Accept", w.Header().Get("Vary"))
+		w = httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewGoneError(""), w)
+		require.Equal(t, "max-age=3600", w.Header().Get("Cache-Control"))
+		w = httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewNotFoundError("").AddHeader("Cache-Control", "no-cache"), w)
+		require.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		require.Empty(t, w.Header().Get("Expires"))
+		w = httptest.NewRecorder()
+		w.Header().Set("Vary", "*")
+		DefaultErrorWriter.WriteError(NewNotFoundError(""), w)
+		require.Equal(t, []string{"*"}, w.Header().Values("Vary"))
+	})
+}
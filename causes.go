@@ -0,0 +1,115 @@
+package httperr
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CauseEntry describes an error in the cause chain (or tree) of an error
+//
+// cause entries are written to the response body by the DefaultErrorWriter (when DefaultErrorWriterShowCause is set)
+type CauseEntry struct {
+	// Type is the Go type name of the error
+	Type string `json:"type"`
+	// Message is the error message
+	Message string `json:"message"`
+	// Status is the status code of the error (only if the error is a StatusError)
+	Status int `json:"status,omitempty"`
+	// Stack is the call stack info of the error (only if the error has stack info)
+	Stack []string `json:"stack,omitempty"`
+	// Causes are the errors wrapped by the error
+	//
+	// an error created with multiple %w verbs (or errors.Join) has more than one cause
+	Causes []CauseEntry `json:"causes,omitempty"`
+}
+
+// CauseTree returns the cause entries for all errors wrapped by the supplied error
+//
+// both single (Unwrap() error) and multiple (Unwrap() []error) wrapped errors are followed - up to MaxCauseDepth
+func CauseTree(err error) []CauseEntry {
+	return causeEntries(err, true, 0)
+}
+
+type stackInfoError interface {
+	StackInfo() StackInfo
+}
+
+func causeEntries(err error, withStack bool, depth uint) []CauseEntry {
+	causes := unwrapAll(err)
+	if len(causes) == 0 || depth >= MaxCauseDepth {
+		return nil
+	}
+	result := make([]CauseEntry, 0, len(causes))
+	for _, cause := range causes {
+		entry := CauseEntry{
+			Type:    fmt.Sprintf("%T", cause),
+			Message: cause.Error(),
+			Causes:  causeEntries(cause, withStack, depth+1),
+		}
+		if se, ok := cause.(StatusError); ok {
+			entry.Status = se.StatusCode()
+		}
+		if si, ok := cause.(stackInfoError); ok && withStack {
+			entry.Stack = stackStrings(si.StackInfo())
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+func unwrapAll(err error) []error {
+	switch et := err.(type) {
+	case interface{ Unwrap() []error }:
+		result := make([]error, 0, len(et.Unwrap()))
+		for _, e := range et.Unwrap() {
+			if e != nil {
+				result = append(result, e)
+			}
+		}
+		return result
+	case interface{ Unwrap() error }:
+		if cause := et.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	}
+	return nil
+}
+
+func stackStrings(stack StackInfo) []string {
+	if len(stack) == 0 {
+		return nil
+	}
+	result := make([]string, len(stack))
+	for i, f := range stack {
+		result[i] = fmt.Sprintf("%s:%d", f.Function, f.Line)
+	}
+	return result
+}
+
+func writeCauseTree(w io.Writer, err error, depth uint) {
+	if depth >= MaxCauseDepth {
+		return
+	}
+	indent := strings.Repeat("\t", int(depth)+1)
+	for _, cause := range unwrapAll(err) {
+		_, _ = fmt.Fprintf(w, "\n%s%T", indent, cause)
+		if se, ok := cause.(StatusError); ok {
+			_, _ = fmt.Fprintf(w, " (%d)", se.StatusCode())
+		}
+		if _, ok := cause.(*httpError); !ok {
+			if _, ok := cause.(fmt.Formatter); ok {
+				// the cause formats its own detail (and, usually, its own causes)
+				_, _ = io.WriteString(w, ": "+strings.ReplaceAll(fmt.Sprintf("%+v", cause), "\n", "\n"+indent))
+				continue
+			}
+		}
+		_, _ = io.WriteString(w, ": "+cause.Error())
+		if si, ok := cause.(stackInfoError); ok && DefaultFrameFormatter != nil {
+			for _, fr := range si.StackInfo() {
+				_, _ = io.WriteString(w, strings.ReplaceAll(DefaultFrameFormatter.FrameLine(fr), "\n", "\n"+indent))
+			}
+		}
+		writeCauseTree(w, cause, depth+1)
+	}
+}
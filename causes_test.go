@@ -0,0 +1,55 @@
+package httperr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestCauseTree(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		require.Empty(t, CauseTree(nil))
+	})
+	t.Run("no causes", func(t *testing.T) {
+		require.Empty(t, CauseTree(errors.New("no causes")))
+	})
+	t.Run("chain", func(t *testing.T) {
+		e := NewBadRequestError("whoops").WithCause(fmt.Errorf("wrapped: %w", errors.New("inner")))
+		causes := CauseTree(e)
+		require.Len(t, causes, 1)
+		require.Equal(t, "*fmt.wrapError", causes[0].Type)
+		require.Equal(t, "wrapped: inner", causes[0].Message)
+		require.Len(t, causes[0].Causes, 1)
+		require.Equal(t, "*errors.errorString", causes[0].Causes[0].Type)
+		require.Equal(t, "inner", causes[0].Causes[0].Message)
+		require.Empty(t, causes[0].Causes[0].Causes)
+	})
+	t.Run("tree", func(t *testing.T) {
+		DefaultPackageName = "httperr"
+		defer func() {
+			DefaultPackageName = ""
+		}()
+		e := errors.Join(NewNotFoundError("not found"), errors.New("other"))
+		causes := CauseTree(e)
+		require.Len(t, causes, 2)
+		require.Equal(t, "*httperr.httpError", causes[0].Type)
+		require.Equal(t, http.StatusNotFound, causes[0].Status)
+		require.Len(t, causes[0].Stack, 1)
+		require.Contains(t, causes[0].Stack[0], ".TestCauseTree.")
+		require.Equal(t, "*errors.errorString", causes[1].Type)
+		require.Equal(t, 0, causes[1].Status)
+		require.Empty(t, causes[1].Stack)
+	})
+	t.Run("max depth", func(t *testing.T) {
+		defer func(d uint) {
+			MaxCauseDepth = d
+		}(MaxCauseDepth)
+		MaxCauseDepth = 1
+		e := fmt.Errorf("outer: %w", fmt.Errorf("middle: %w", errors.New("inner")))
+		causes := CauseTree(e)
+		require.Len(t, causes, 1)
+		require.Empty(t, causes[0].Causes)
+	})
+}
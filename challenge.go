@@ -0,0 +1,317 @@
+package httperr
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ChallengeSetter is a HttpError with authentication challenges
+//
+// implemented by all errors created by this package (but not part of HttpError) - for example...
+//
+//	if cs, ok := err.(httperr.ChallengeSetter); ok {
+//		cs.WithChallenges(httperr.BearerChallenge("api"))
+//	}
+type ChallengeSetter interface {
+	HttpError
+	ChallengeError
+	// WithChallenges adds the supplied authentication challenges to the error
+	//
+	// each challenge is added as a WWW-Authenticate header (or Proxy-Authenticate header for 407 Proxy Authentication Required)
+	WithChallenges(challenges ...Challenge) ChallengeSetter
+}
+
+var _ ChallengeSetter = (*httpError)(nil)
+
+// Challenge is an authentication challenge - written as a WWW-Authenticate (or Proxy-Authenticate) header
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-challenge-and-response
+type Challenge struct {
+	// Scheme is the authentication scheme (e.g. "Basic", "Bearer")
+	Scheme string
+	// Token68 is the (optional) token68 of the challenge - if set, Params are not written
+	Token68 string
+	// Params are the auth-params of the challenge
+	Params []AuthParam
+}
+
+// AuthParam is an auth-param of a Challenge
+type AuthParam struct {
+	Name  string
+	Value string
+	// Token determines whether the value is written as a token (rather than a quoted-string)
+	//
+	// if the value is not a valid token, it is written as a quoted-string regardless
+	Token bool
+}
+
+// Bearer error codes
+//
+// see https://www.rfc-editor.org/rfc/rfc6750.html#section-3.1
+const (
+	BearerInvalidRequest    = "invalid_request"
+	BearerInvalidToken      = "invalid_token"
+	BearerInsufficientScope = "insufficient_scope"
+)
+
+// NewChallenge creates a new Challenge for the specified scheme
+func NewChallenge(scheme string) Challenge {
+	return Challenge{Scheme: scheme}
+}
+
+// BasicChallenge creates a new Basic authentication challenge (with charset="UTF-8")
+//
+// see https://www.rfc-editor.org/rfc/rfc7617.html#section-2
+func BasicChallenge(realm string) Challenge {
+	return NewChallenge("Basic").WithRealm(realm).WithParam("charset", "UTF-8")
+}
+
+// BearerChallenge creates a new Bearer authentication challenge
+//
+// use WithError, WithErrorDescription and WithScope to add the bearer specific params
+//
+// see https://www.rfc-editor.org/rfc/rfc6750.html#section-3
+func BearerChallenge(realm string) Challenge {
+	return NewChallenge("Bearer").WithRealm(realm)
+}
+
+// DigestChallenge creates a new Digest authentication challenge (with qop="auth" and algorithm=SHA-256)
+//
+// see https://www.rfc-editor.org/rfc/rfc7616.html#section-3.3
+func DigestChallenge(realm string, nonce string, opaque string) Challenge {
+	c := NewChallenge("Digest").WithRealm(realm).
+		WithParam("qop", "auth").
+		WithTokenParam("algorithm", "SHA-256").
+		WithParam("nonce", nonce)
+	if opaque != "" {
+		c = c.WithParam("opaque", opaque)
+	}
+	return c
+}
+
+// WithRealm returns the challenge with a realm param
+func (c Challenge) WithRealm(realm string) Challenge {
+	if realm == "" {
+		return c
+	}
+	return c.WithParam("realm", realm)
+}
+
+// WithParam returns the challenge with an added param (written as a quoted-string)
+func (c Challenge) WithParam(name string, value string) Challenge {
+	c.Params = append(c.Params[:len(c.Params):len(c.Params)], AuthParam{Name: name, Value: value})
+	return c
+}
+
+// WithTokenParam returns the challenge with an added param (written as a token)
+func (c Challenge) WithTokenParam(name string, value string) Challenge {
+	c.Params = append(c.Params[:len(c.Params):len(c.Params)], AuthParam{Name: name, Value: value, Token: true})
+	return c
+}
+
+// WithToken68 returns the challenge with a token68
+func (c Challenge) WithToken68(token string) Challenge {
+	c.Token68 = token
+	return c
+}
+
+// WithError returns the (bearer) challenge with an error param (e.g. BearerInvalidToken)
+func (c Challenge) WithError(code string) Challenge {
+	return c.WithParam("error", code)
+}
+
+// WithErrorDescription returns the (bearer) challenge with an error_description param
+func (c Challenge) WithErrorDescription(description string) Challenge {
+	return c.WithParam("error_description", description)
+}
+
+// WithScope returns the (bearer) challenge with a scope param (scopes are space delimited)
+func (c Challenge) WithScope(scopes ...string) Challenge {
+	return c.WithParam("scope", strings.Join(scopes, " "))
+}
+
+// WithStale returns the (digest) challenge with stale=true
+func (c Challenge) WithStale() Challenge {
+	return c.WithTokenParam("stale", "true")
+}
+
+// String returns the challenge as a header value
+func (c Challenge) String() string {
+	var sb strings.Builder
+	sb.WriteString(c.Scheme)
+	if c.Token68 != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(c.Token68)
+		return sb.String()
+	}
+	for i, p := range c.Params {
+		if i == 0 {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p.Name)
+		sb.WriteByte('=')
+		if p.Token && isToken(p.Value) {
+			sb.WriteString(p.Value)
+		} else {
+			writeQuoted(&sb, p.Value)
+		}
+	}
+	return sb.String()
+}
+
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isTokenChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeQuoted writes a quoted-string (escaping quotes and backslashes)
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-quoted-strings
+func writeQuoted(sb *strings.Builder, s string) {
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if b := s[i]; b == '"' || b == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte('"')
+}
+
+const (
+	hdrWWWAuthenticate   = "WWW-Authenticate"
+	hdrProxyAuthenticate = "Proxy-Authenticate"
+)
+
+func challengeHeader(status int) string {
+	if status == http.StatusProxyAuthRequired {
+		return hdrProxyAuthenticate
+	}
+	return hdrWWWAuthenticate
+}
+
+// parseChallenges parses the challenges of a WWW-Authenticate (or Proxy-Authenticate) header value
+//
+// a header value may contain multiple (comma separated) challenges - unquoted param values are parsed as
+// token params (so that the challenge String is the same as the header value) and parsing stops at
+// anything malformed
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-www-authenticate
+func parseChallenges(value string) (result []Challenge) {
+	p := &challengeParser{s: value}
+	for {
+		p.skip(" \t,")
+		scheme := p.token()
+		if scheme == "" {
+			return result
+		}
+		c := NewChallenge(scheme)
+		if p.skip(" ") > 0 {
+			if t68, ok := p.token68(); ok {
+				c.Token68 = t68
+			} else {
+				p.params(&c)
+			}
+		}
+		result = append(result, c)
+	}
+}
+
+type challengeParser struct {
+	s   string
+	pos int
+}
+
+func (p *challengeParser) skip(chars string) int {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(chars, p.s[p.pos]) != -1 {
+		p.pos++
+	}
+	return p.pos - start
+}
+
+func (p *challengeParser) token() string {
+	start := p.pos
+	for p.pos < len(p.s) && isTokenChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// token68 reads a token68 (if that is what follows) - otherwise the position is unchanged
+func (p *challengeParser) token68() (string, bool) {
+	start := p.pos
+	for p.pos < len(p.s) && (isAlphaNum(p.s[p.pos]) || strings.IndexByte("-._~+/", p.s[p.pos]) != -1) {
+		p.pos++
+	}
+	end := p.pos
+	if end > start {
+		p.skip("=")
+		end = p.pos
+		p.skip(" \t")
+		if p.pos == len(p.s) || p.s[p.pos] == ',' {
+			return p.s[start:end], true
+		}
+	}
+	p.pos = start
+	return "", false
+}
+
+// params reads the auth-params of a challenge - stopping at the start of the next challenge
+func (p *challengeParser) params(c *Challenge) {
+	for {
+		start := p.pos
+		name := p.token()
+		p.skip(" \t")
+		if name == "" || p.pos == len(p.s) || p.s[p.pos] != '=' {
+			// not a param - so the start of the next challenge
+			p.pos = start
+			return
+		}
+		p.pos++
+		p.skip(" \t")
+		if p.pos < len(p.s) && p.s[p.pos] == '"' {
+			c.Params = append(c.Params, AuthParam{Name: name, Value: p.quoted()})
+		} else {
+			c.Params = append(c.Params, AuthParam{Name: name, Value: p.token(), Token: true})
+		}
+		p.skip(" \t")
+		if p.pos == len(p.s) || p.s[p.pos] != ',' {
+			return
+		}
+		p.skip(" \t,")
+	}
+}
+
+// quoted reads a quoted-string (un-escaping quoted-pairs)
+func (p *challengeParser) quoted() string {
+	var sb strings.Builder
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		switch b := p.s[p.pos]; b {
+		case '"':
+			p.pos++
+			return sb.String()
+		case '\\':
+			if p.pos+1 < len(p.s) {
+				p.pos++
+			}
+			sb.WriteByte(p.s[p.pos])
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	return sb.String()
+}
+
+func isAlphaNum(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
@@ -0,0 +1,127 @@
+package httperr
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChallenge_String(t *testing.T) {
+	testCases := []struct {
+		challenge Challenge
+		expect    string
+	}{
+		{
+			challenge: NewChallenge("Custom"),
+			expect:    "Custom",
+		},
+		{
+			challenge: BasicChallenge("api"),
+			expect:    `Basic realm="api", charset="UTF-8"`,
+		},
+		{
+			challenge: BasicChallenge(`my "quoted" \ realm`),
+			expect:    `Basic realm="my \"quoted\" \\ realm", charset="UTF-8"`,
+		},
+		{
+			challenge: BearerChallenge("example"),
+			expect:    `Bearer realm="example"`,
+		},
+		{
+			challenge: BearerChallenge("example").WithError(BearerInvalidToken).WithErrorDescription("The access token expired"),
+			expect:    `Bearer realm="example", error="invalid_token", error_description="The access token expired"`,
+		},
+		{
+			challenge: BearerChallenge("").WithError(BearerInsufficientScope).WithScope("read", "write"),
+			expect:    `Bearer error="insufficient_scope", scope="read write"`,
+		},
+		{
+			challenge: DigestChallenge("http-auth@example.org", "abc123", "xyz"),
+			expect:    `Digest realm="http-auth@example.org", qop="auth", algorithm=SHA-256, nonce="abc123", opaque="xyz"`,
+		},
+		{
+			challenge: DigestChallenge("api", "abc123", "").WithStale(),
+			expect:    `Digest realm="api", qop="auth", algorithm=SHA-256, nonce="abc123", stale=true`,
+		},
+		{
+			challenge: NewChallenge("Custom").WithTokenParam("a", "not a token"),
+			expect:    `Custom a="not a token"`,
+		},
+		{
+			challenge: NewChallenge("Negotiate").WithToken68("YIIB=="),
+			expect:    `Negotiate YIIB==`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expect, func(t *testing.T) {
+			require.Equal(t, tc.expect, tc.challenge.String())
+		})
+	}
+}
+
+func TestChallenge_WithParam_NoAliasing(t *testing.T) {
+	base := NewChallenge("Bearer").WithRealm("a").WithParam("x", "1")
+	c1 := base.WithParam("y", "2")
+	c2 := base.WithParam("z", "3")
+	require.Equal(t, `Bearer realm="a", x="1", y="2"`, c1.String())
+	require.Equal(t, `Bearer realm="a", x="1", z="3"`, c2.String())
+}
+
+func TestParseChallenges(t *testing.T) {
+	testCases := []struct {
+		value  string
+		expect []Challenge
+	}{
+		{value: ``},
+		{value: `Basic`, expect: []Challenge{NewChallenge("Basic")}},
+		{value: `Basic realm="api", charset="UTF-8"`, expect: []Challenge{BasicChallenge("api")}},
+		{value: `Bearer realm="api", error="invalid_token"`, expect: []Challenge{BearerChallenge("api").WithError(BearerInvalidToken)}},
+		{value: `Negotiate YIIB==`, expect: []Challenge{NewChallenge("Negotiate").WithToken68("YIIB==")}},
+		{value: `Custom a=b, c = "d \"e\""`, expect: []Challenge{NewChallenge("Custom").WithTokenParam("a", "b").WithParam("c", `d "e"`)}},
+		{
+			value: `Newauth realm="apps", type=1, title="Login", Basic realm="simple", Negotiate abc, Bearer`,
+			expect: []Challenge{
+				NewChallenge("Newauth").WithRealm("apps").WithTokenParam("type", "1").WithParam("title", "Login"),
+				NewChallenge("Basic").WithRealm("simple"),
+				NewChallenge("Negotiate").WithToken68("abc"),
+				NewChallenge("Bearer"),
+			},
+		},
+		{value: `Basic realm="a", "junk"`, expect: []Challenge{NewChallenge("Basic").WithRealm("a")}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			require.Equal(t, tc.expect, parseChallenges(tc.value))
+		})
+	}
+}
+
+func TestError_WithChallenges(t *testing.T) {
+	e := NewUnauthorizedError("").(ChallengeSetter).WithChallenges(BasicChallenge("api"), BearerChallenge("api"))
+	require.Equal(t, []Challenge{BasicChallenge("api"), BearerChallenge("api")}, e.Challenges())
+	require.Equal(t, []string{`Basic realm="api", charset="UTF-8"`, `Bearer realm="api"`}, e.(HeaderSetter).Header().Values("WWW-Authenticate"))
+
+	e = NewProxyAuthRequiredError("").(ChallengeSetter).WithChallenges(BasicChallenge("proxy"))
+	require.Len(t, e.Challenges(), 1)
+	require.Empty(t, e.(HeaderSetter).Header().Values("WWW-Authenticate"))
+	require.Equal(t, []string{`Basic realm="proxy", charset="UTF-8"`}, e.(HeaderSetter).Header().Values("Proxy-Authenticate"))
+}
+
+func TestDefaultErrorWriter_Challenges(t *testing.T) {
+	w := httptest.NewRecorder()
+	e := NewUnauthorizedChallengeError("", BasicChallenge("api"), BearerChallenge("api").WithError(BearerInvalidToken))
+	DefaultErrorWriter.WriteError(e, w)
+	require.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+	require.Equal(t, []string{`Basic realm="api", charset="UTF-8"`, `Bearer realm="api", error="invalid_token"`}, w.Result().Header.Values("WWW-Authenticate"))
+}
+
+func TestError_Challenges_FromHeader(t *testing.T) {
+	e := NewUnauthorizedError("").AddHeader("WWW-Authenticate", `Bearer realm="api"`)
+	require.Equal(t, []Challenge{BearerChallenge("api")}, e.(ChallengeError).Challenges())
+
+	w := httptest.NewRecorder()
+	DefaultErrorWriter.WriteError(NewUnauthorizedChallengeError("", BearerChallenge("api")), w)
+	e = FromResponse(w.Result())
+	require.Equal(t, []Challenge{BearerChallenge("api")}, e.(ChallengeError).Challenges())
+}
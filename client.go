@@ -0,0 +1,78 @@
+package httperr
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// MaxResponseBodySize is the maximum size of response body read by FromResponse
+var MaxResponseBodySize int64 = 1 << 20
+
+// FromResponse creates a HttpError from an error response (i.e. a response with a 4xx or 5xx status)
+//
+// the response body json is decoded according to the DefaultResponseFormat - so responses written by
+// the DefaultErrorWriter have their message, reasons and retryable hint restored.  The response headers are
+// copied to the error (so, for example, RetryAfterError, ChallengeError and AllowError are available using errors.As)
+//
+// returns nil if the response status is not an error status
+//
+// Note: the response body is read, but not closed
+func FromResponse(resp *http.Response) HttpError {
+	if resp == nil || resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	result := &httpError{
+		message: http.StatusText(resp.StatusCode),
+		status:  resp.StatusCode,
+		headers: resp.Header.Clone(),
+	}
+	if result.headers == nil {
+		result.headers = make(http.Header)
+	}
+	if ra, ok := ParseRetryAfter(result.headers.Get(hdrRetryAfter)); ok {
+		result.retryAfter = ra
+	}
+	if resp.Body != nil && isJsonContentType(resp.Header.Get(hdrContentType)) {
+		decodeResponseBody(result, io.LimitReader(resp.Body, MaxResponseBodySize))
+	}
+	return result
+}
+
+func isJsonContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == applicationJson || (len(mt) > 5 && mt[len(mt)-5:] == "+json"))
+}
+
+func decodeResponseBody(e *httpError, r io.Reader) {
+	rf := DefaultResponseFormat
+	body := map[string]json.RawMessage{}
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return
+	}
+	if rf != nil && rf.Envelope != "" {
+		inner := map[string]json.RawMessage{}
+		if err := json.Unmarshal(body[rf.Envelope], &inner); err != nil {
+			return
+		}
+		body = inner
+	}
+	var msg string
+	if json.Unmarshal(body[rf.Name(PropertyError)], &msg) == nil && msg != "" {
+		e.message = msg
+	}
+	var reasons []any
+	if json.Unmarshal(body[rf.Name(PropertyReasons)], &reasons) == nil && len(reasons) > 0 {
+		e.reasons = reasons
+	}
+	var retryable bool
+	if json.Unmarshal(body[rf.Name(PropertyRetryable)], &retryable) == nil {
+		e.retryable = &retryable
+	}
+	var secs int64
+	if json.Unmarshal(body[rf.Name(PropertyRetryAfter)], &secs) == nil && e.retryAfter.IsZero() && secs > 0 {
+		e.retryAfter = RetryAfter{Delay: time.Duration(secs) * time.Second}
+	}
+}
@@ -0,0 +1,115 @@
+package httperr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromResponse(t *testing.T) {
+	t.Run("nil and non-error", func(t *testing.T) {
+		require.Nil(t, FromResponse(nil))
+		require.Nil(t, FromResponse(&http.Response{StatusCode: http.StatusOK}))
+		require.Nil(t, FromResponse(&http.Response{StatusCode: http.StatusFound}))
+	})
+	t.Run("round trip", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewServiceUnavailableRetryError("maintenance", nil, time.Minute).(RetryableSetter).
+			WithRetryable(false).
+			AddReasons("down", map[string]any{"code": "x"}), w)
+		e := FromResponse(w.Result())
+		require.Error(t, e)
+		require.Equal(t, http.StatusServiceUnavailable, e.StatusCode())
+		require.Equal(t, "maintenance", e.Error())
+		require.Equal(t, []any{"down", map[string]any{"code": "x"}}, e.Reasons())
+		require.False(t, e.(RetryableSetter).Retryable())
+		ra, ok := e.(RetryAfterError).RetryAfter()
+		require.True(t, ok)
+		require.Equal(t, time.Minute, ra.Delay)
+		require.Equal(t, "nosniff", e.(HeaderSetter).Header().Get("X-Content-Type-Options"))
+	})
+	t.Run("retryable by status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewGatewayTimeoutError("slow"), w)
+		e := FromResponse(w.Result())
+		require.True(t, e.(RetryableSetter).Retryable())
+		require.True(t, e.(RetryableSetter).Timeout())
+	})
+	t.Run("retry after from body", func(t *testing.T) {
+		resp := &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Content-Type": {"application/problem+json"}},
+			Body:       http.NoBody,
+		}
+		e := FromResponse(resp)
+		require.Equal(t, "Too Many Requests", e.Error())
+		resp.Body = readCloser(`{"$retryAfter":5}`)
+		e = FromResponse(resp)
+		ra, ok := e.(RetryAfterError).RetryAfter()
+		require.True(t, ok)
+		require.Equal(t, 5*time.Second, ra.Delay)
+	})
+	t.Run("envelope", func(t *testing.T) {
+		DefaultResponseFormat = &ResponseFormat{
+			Naming:     NamingCamel,
+			Envelope:   "error",
+			Properties: map[string]string{PropertyError: "message"},
+		}
+		defer func() {
+			DefaultResponseFormat = &ResponseFormat{}
+		}()
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewConflictError("already exists").(RetryableSetter).WithRetryable(true), w)
+		e := FromResponse(w.Result())
+		require.Equal(t, "already exists", e.Error())
+		require.True(t, e.(RetryableSetter).Retryable())
+	})
+	t.Run("non json", func(t *testing.T) {
+		resp := &http.Response{
+			StatusCode: http.StatusBadGateway,
+			Header:     http.Header{"Content-Type": {"text/html"}},
+			Body:       readCloser("<html>bad gateway</html>"),
+		}
+		e := FromResponse(resp)
+		require.Equal(t, "Bad Gateway", e.Error())
+		require.True(t, e.(RetryableSetter).Retryable())
+	})
+	t.Run("invalid json", func(t *testing.T) {
+		resp := &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       readCloser("{not json"),
+		}
+		e := FromResponse(resp)
+		require.Equal(t, "Bad Request", e.Error())
+		require.Empty(t, e.Reasons())
+	})
+	t.Run("accessors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewUnauthorizedChallengeError("", BearerChallenge("api")).AddHeader("Retry-After", "5"), w)
+		err := fmt.Errorf("wrapped: %w", FromResponse(w.Result()))
+		var rae RetryAfterError
+		require.True(t, errors.As(err, &rae))
+		ra, ok := rae.RetryAfter()
+		require.True(t, ok)
+		require.Equal(t, 5*time.Second, ra.Delay)
+		var ce ChallengeError
+		require.True(t, errors.As(err, &ce))
+		require.Equal(t, []Challenge{BearerChallenge("api")}, ce.Challenges())
+	})
+	t.Run("no headers", func(t *testing.T) {
+		e := FromResponse(&http.Response{StatusCode: http.StatusNotFound})
+		require.Equal(t, "Not Found", e.Error())
+		require.NotNil(t, e.(HeaderSetter).Header())
+	})
+}
+
+func readCloser(s string) io.ReadCloser {
+	return io.NopCloser(strings.NewReader(s))
+}
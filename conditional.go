@@ -0,0 +1,157 @@
+package httperr
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	hdrETag              = "ETag"
+	hdrIfMatch           = "If-Match"
+	hdrIfNoneMatch       = "If-None-Match"
+	hdrIfModifiedSince   = "If-Modified-Since"
+	hdrIfUnmodifiedSince = "If-Unmodified-Since"
+)
+
+// CodePrecondition is the reason code used by CheckPreconditions when a precondition fails
+const CodePrecondition = "precondition"
+
+// CheckPreconditions evaluates the conditional request headers (If-Match, If-Unmodified-Since, If-None-Match and
+// If-Modified-Since) against the current entity tag and modification time of the resource
+//
+// the etag should be a quoted entity tag (e.g. `"abc"` or `W/"abc"`) - an unquoted etag is treated as a strong entity tag.
+// An empty etag and zero modTime indicates that the resource does not exist (so, for example, `If-None-Match: *` passes)
+//
+// preconditions are evaluated in the order specified by RFC 9110 (see https://www.rfc-editor.org/rfc/rfc9110.html#name-precedence-of-preconditions)
+// and returns:
+//   - nil if all preconditions pass (or there are no conditional headers)
+//   - 304 Not Modified (with ETag header) for a GET or HEAD request where the resource has not changed
+//   - 412 Precondition Failed where a precondition fails - with a reason whose Field is the failed header
+func CheckPreconditions(r *http.Request, etag string, modTime time.Time) HttpError {
+	if status, header := checkPreconditions(r, normalizeETag(etag), modTime.Truncate(time.Second)); status != 0 {
+		result := newError(status, "", nil, getStackInfo())
+		if status == http.StatusNotModified {
+			if etag != "" {
+				result.SetHeader(hdrETag, normalizeETag(etag))
+			}
+		} else {
+			result.AddReasons(FieldReason(header, "precondition failed").WithCode(CodePrecondition).WithMeta("in", InHeader))
+		}
+		return result
+	}
+	return nil
+}
+
+// RequirePreconditions returns a 428 Precondition Required error if the request method is unsafe (i.e. not GET, HEAD,
+// OPTIONS or TRACE) and the request has no If-Match header - otherwise returns nil
+//
+// used to enforce optimistic locking - so that clients cannot update a resource without stating the version they expect
+func RequirePreconditions(r *http.Request) HttpError {
+	if !isSafeMethod(r.Method) && r.Header.Get(hdrIfMatch) == "" {
+		return newError(http.StatusPreconditionRequired, "", nil, getStackInfo()).
+			AddReasons(FieldReason(hdrIfMatch, "is required").WithCode(CodeRequired).WithMeta("in", InHeader))
+	}
+	return nil
+}
+
+func checkPreconditions(r *http.Request, etag string, modTime time.Time) (int, string) {
+	exists := etag != "" || !modTime.IsZero()
+	if im := r.Header.Get(hdrIfMatch); im != "" {
+		if !exists || !matchETag(im, etag, false) {
+			return http.StatusPreconditionFailed, hdrIfMatch
+		}
+	} else if ius := r.Header.Get(hdrIfUnmodifiedSince); ius != "" && !modTime.IsZero() {
+		if t, err := http.ParseTime(ius); err == nil && modTime.After(t) {
+			return http.StatusPreconditionFailed, hdrIfUnmodifiedSince
+		}
+	}
+	getOrHead := r.Method == http.MethodGet || r.Method == http.MethodHead
+	if inm := r.Header.Get(hdrIfNoneMatch); inm != "" {
+		if exists && matchETag(inm, etag, true) {
+			if getOrHead {
+				return http.StatusNotModified, hdrIfNoneMatch
+			}
+			return http.StatusPreconditionFailed, hdrIfNoneMatch
+		}
+	} else if ims := r.Header.Get(hdrIfModifiedSince); ims != "" && getOrHead && !modTime.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil && !modTime.After(t) {
+			return http.StatusNotModified, hdrIfModifiedSince
+		}
+	}
+	return 0, ""
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func normalizeETag(etag string) string {
+	if etag == "" || strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// matchETag reports whether the header value (a list of entity tags or "*") matches the etag
+//
+// uses weak comparison if weak is true, otherwise strong comparison (see https://www.rfc-editor.org/rfc/rfc9110.html#name-comparison-2)
+func matchETag(header string, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if etag == "" {
+		return false
+	}
+	opaque, isWeak := splitETag(etag)
+	if isWeak && !weak {
+		return false
+	}
+	for header != "" {
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			break
+		}
+		tag, rest, ok := scanETag(header)
+		if !ok {
+			return false
+		}
+		header = rest
+		if tagOpaque, tagWeak := splitETag(tag); tagOpaque == opaque && (weak || !tagWeak) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitETag(etag string) (string, bool) {
+	if strings.HasPrefix(etag, "W/") {
+		return etag[2:], true
+	}
+	return etag, false
+}
+
+// scanETag scans the first entity tag from s - returning the tag and the remainder
+func scanETag(s string) (string, string, bool) {
+	start := 0
+	if strings.HasPrefix(s, "W/") {
+		start = 2
+	}
+	if len(s) <= start || s[start] != '"' {
+		return "", "", false
+	}
+	for i := start + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return s[:i+1], s[i+1:], true
+		case c == 0x21 || (c >= 0x23 && c <= 0x7e) || c >= 0x80:
+		default:
+			return "", "", false
+		}
+	}
+	return "", "", false
+}
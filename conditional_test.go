@@ -0,0 +1,113 @@
+package httperr
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 500, time.UTC)
+	before := modTime.Add(-time.Hour).Format(http.TimeFormat)
+	at := modTime.Format(http.TimeFormat)
+	testCases := []struct {
+		method  string
+		headers map[string]string
+		etag    string
+		modTime time.Time
+		expect  int
+		header  string
+	}{
+		{method: http.MethodGet, etag: `"a"`, modTime: modTime},
+		{method: http.MethodGet, headers: map[string]string{hdrIfNoneMatch: `"a"`}, etag: `"a"`, expect: http.StatusNotModified},
+		{method: http.MethodHead, headers: map[string]string{hdrIfNoneMatch: `"x", W/"a"`}, etag: `"a"`, expect: http.StatusNotModified},
+		{method: http.MethodGet, headers: map[string]string{hdrIfNoneMatch: `*`}, etag: `"a"`, expect: http.StatusNotModified},
+		{method: http.MethodGet, headers: map[string]string{hdrIfNoneMatch: `"b"`}, etag: `"a"`},
+		{method: http.MethodGet, headers: map[string]string{hdrIfNoneMatch: `"b"`, hdrIfModifiedSince: at}, etag: `"a"`, modTime: modTime},
+		{method: http.MethodPut, headers: map[string]string{hdrIfNoneMatch: `*`}, etag: `"a"`, expect: http.StatusPreconditionFailed, header: hdrIfNoneMatch},
+		{method: http.MethodPut, headers: map[string]string{hdrIfNoneMatch: `*`}},
+		{method: http.MethodGet, headers: map[string]string{hdrIfModifiedSince: at}, modTime: modTime, expect: http.StatusNotModified},
+		{method: http.MethodGet, headers: map[string]string{hdrIfModifiedSince: before}, modTime: modTime},
+		{method: http.MethodGet, headers: map[string]string{hdrIfModifiedSince: "garbage"}, modTime: modTime},
+		{method: http.MethodPost, headers: map[string]string{hdrIfModifiedSince: at}, modTime: modTime},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `"a"`}, etag: `"a"`},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `"x", "a"`}, etag: "a"},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `*`}, etag: `"a"`},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `*`}, expect: http.StatusPreconditionFailed, header: hdrIfMatch},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `"b"`}, etag: `"a"`, expect: http.StatusPreconditionFailed, header: hdrIfMatch},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `W/"a"`}, etag: `"a"`, expect: http.StatusPreconditionFailed, header: hdrIfMatch},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `"a"`}, etag: `W/"a"`, expect: http.StatusPreconditionFailed, header: hdrIfMatch},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `bad`}, etag: `"a"`, expect: http.StatusPreconditionFailed, header: hdrIfMatch},
+		{method: http.MethodPut, headers: map[string]string{hdrIfMatch: `"a"`, hdrIfUnmodifiedSince: before}, etag: `"a"`, modTime: modTime},
+		{method: http.MethodPut, headers: map[string]string{hdrIfUnmodifiedSince: before}, modTime: modTime, expect: http.StatusPreconditionFailed, header: hdrIfUnmodifiedSince},
+		{method: http.MethodPut, headers: map[string]string{hdrIfUnmodifiedSince: at}, modTime: modTime},
+		{method: http.MethodPut, headers: map[string]string{hdrIfUnmodifiedSince: before}},
+		{method: http.MethodGet, headers: map[string]string{hdrIfMatch: `"a"`, hdrIfNoneMatch: `"a"`}, etag: `"a"`, expect: http.StatusNotModified},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest(tc.method, "/", nil)
+		for k, v := range tc.headers {
+			r.Header.Set(k, v)
+		}
+		err := CheckPreconditions(r, tc.etag, tc.modTime)
+		if tc.expect == 0 {
+			require.Nil(t, err, i)
+			continue
+		}
+		require.Error(t, err, i)
+		require.Equal(t, tc.expect, err.StatusCode(), i)
+		require.Contains(t, err.StackInfo()[0].Function, "TestCheckPreconditions", i)
+		if tc.expect == http.StatusNotModified {
+			require.Empty(t, err.Reasons(), i)
+			if tc.etag != "" {
+				require.Equal(t, tc.etag, err.(HeaderSetter).Header().Get(hdrETag), i)
+			}
+		} else {
+			require.Equal(t, []any{Reason{Code: CodePrecondition, Message: "precondition failed", Field: tc.header, Meta: map[string]any{"in": InHeader}}}, err.Reasons(), i)
+		}
+	}
+}
+
+func TestCheckPreconditions_Write(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(hdrIfNoneMatch, `"abc"`)
+	err := CheckPreconditions(r, "abc", time.Time{})
+	require.Error(t, err)
+	w := httptest.NewRecorder()
+	err.(RequestWriter).WriteRequest(w, r)
+	require.Equal(t, http.StatusNotModified, w.Code)
+	require.Equal(t, `"abc"`, w.Header().Get(hdrETag))
+	require.Empty(t, w.Body.String())
+}
+
+func TestRequirePreconditions(t *testing.T) {
+	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace} {
+		require.Nil(t, RequirePreconditions(httptest.NewRequest(m, "/", nil)), m)
+	}
+	r := httptest.NewRequest(http.MethodPut, "/", nil)
+	r.Header.Set(hdrIfMatch, `"a"`)
+	require.Nil(t, RequirePreconditions(r))
+	for _, m := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		err := RequirePreconditions(httptest.NewRequest(m, "/", nil))
+		require.Error(t, err, m)
+		require.Equal(t, http.StatusPreconditionRequired, err.StatusCode(), m)
+		require.Equal(t, []any{Reason{Code: CodeRequired, Message: "is required", Field: hdrIfMatch, Meta: map[string]any{"in": InHeader}}}, err.Reasons())
+		require.Contains(t, err.StackInfo()[0].Function, "TestRequirePreconditions")
+	}
+}
+
+func TestScanETag(t *testing.T) {
+	tag, rest, ok := scanETag(`W/"a,b", "c"`)
+	require.True(t, ok)
+	require.Equal(t, `W/"a,b"`, tag)
+	require.Equal(t, `, "c"`, rest)
+	_, _, ok = scanETag(`"abc`)
+	require.False(t, ok)
+	_, _, ok = scanETag(`"a b"`)
+	require.False(t, ok)
+	_, _, ok = scanETag(`W/`)
+	require.False(t, ok)
+	require.True(t, matchETag(`"x", W/"a,b"`, `"a,b"`, true))
+}
@@ -1,13 +1,13 @@
 package httperr
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"runtime"
 	"slices"
 	"strings"
+	"time"
 )
 
 // StatusError is an error interface that supports status codes
@@ -27,12 +27,17 @@ type HttpError interface {
 	// StackInfo returns the call stack info for the error
 	StackInfo() StackInfo
 	// AddHeaders adds the supplied response headers to the error
+	//
+	// any existing values for the headers are replaced
 	AddHeaders(hdrs map[string]string) HttpError
 	// AddHeader adds the supplied response header to the error
+	//
+	// any existing values for the header are replaced
 	AddHeader(header string, value string) HttpError
 	// Headers returns the additional response headers for the HttpError
 	//
-	// additional headers are written to the response by DefaultErrorWriter
+	// the returned map has canonical header keys and only the first value for each header - it is a copy, so
+	// changes to it do not affect the error (use AddHeader, or HeaderSetter for multi-valued headers)
 	Headers() map[string]string
 	// AddReasons adds the supplied reasons to the error
 	AddReasons(reasons ...any) HttpError
@@ -49,6 +54,49 @@ type HttpError interface {
 	Write(w http.ResponseWriter)
 }
 
+// HeaderSetter is a HttpError with multi-valued response headers
+//
+// implemented by all errors created by this package (but not part of HttpError) - for example...
+//
+//	if hs, ok := err.(httperr.HeaderSetter); ok {
+//		hs.AppendHeader("Link", `</docs>; rel="help"`)
+//	}
+type HeaderSetter interface {
+	HttpError
+	// SetHeader sets the supplied response header on the error - replacing any existing values for the header
+	SetHeader(header string, value string) HeaderSetter
+	// AppendHeader appends a value to the supplied response header on the error
+	//
+	// use this for headers that may have multiple values (e.g. WWW-Authenticate, Link or Set-Cookie)
+	AppendHeader(header string, value string) HeaderSetter
+	// DelHeader deletes the supplied response header from the error
+	DelHeader(header string) HeaderSetter
+	// Header returns the additional response headers for the error (with all values)
+	//
+	// additional headers are written to the response by DefaultErrorWriter
+	Header() http.Header
+}
+
+var _ HeaderSetter = (*httpError)(nil)
+
+// RequestWriter is an error that can be written with knowledge of the request
+//
+// implemented by all errors created by this package (but not part of HttpError) - for example...
+//
+//	if rw, ok := err.(httperr.RequestWriter); ok {
+//		rw.WriteRequest(w, r)
+//	}
+type RequestWriter interface {
+	error
+	// WriteRequest writes the error to the http.ResponseWriter for the request
+	//
+	// it uses the DefaultErrorWriter - if the DefaultErrorWriter is a RequestErrorWriter, the request
+	// is passed to it (so that method aware rules can be applied, e.g. no body for HEAD requests)
+	WriteRequest(w http.ResponseWriter, r *http.Request)
+}
+
+var _ RequestWriter = (*httpError)(nil)
+
 // New creates a new HttpError for the specified status code with stack info
 //
 // if the msg arg is an empty string, the message is derived from http.StatusText for the status code
@@ -78,7 +126,7 @@ func Wrap(cause error, defaultStatus int) HttpError {
 	return newError(DefaultErrorStatusResolver.Resolve(cause, defaultStatus), "", cause, getStackInfo())
 }
 
-func newError(status int, msg string, cause error, si StackInfo) HttpError {
+func newError(status int, msg string, cause error, si StackInfo) *httpError {
 	if msg == "" {
 		msg = http.StatusText(status)
 	}
@@ -87,17 +135,20 @@ func newError(status int, msg string, cause error, si StackInfo) HttpError {
 		stack:   si,
 		cause:   cause,
 		status:  status,
-		headers: make(map[string]string),
+		headers: make(http.Header),
 	}
 }
 
 type httpError struct {
-	message string
-	stack   StackInfo
-	cause   error
-	status  int
-	reasons []any
-	headers map[string]string
+	message    string
+	stack      StackInfo
+	cause      error
+	status     int
+	reasons    []any
+	headers    http.Header
+	retryAfter RetryAfter
+	retryable  *bool
+	timeout    *bool
 }
 
 var _ error = (*httpError)(nil)
@@ -105,23 +156,7 @@ var _ HttpError = (*httpError)(nil)
 var _ fmt.Formatter = (*httpError)(nil)
 
 func (e *httpError) MarshalJSON() ([]byte, error) {
-	m := map[string]any{
-		ptyError: e.message,
-	}
-	if e.reasons != nil {
-		m[ptyReasons] = e.reasons
-	}
-	if e.cause != nil && DefaultErrorWriterShowCause {
-		m[ptyCause] = e.cause.Error()
-	}
-	if len(e.stack) > 0 && DefaultErrorWriterShowStack {
-		info := make([]string, len(e.stack))
-		for i, f := range e.stack {
-			info[i] = fmt.Sprintf("%s:%d", f.Function, f.Line)
-		}
-		m[ptyStack] = info
-	}
-	return json.Marshal(m)
+	return DefaultResponseFormat.appendBody(nil, e.status, e.message, e, e, false)
 }
 
 func (e *httpError) StatusCode() int {
@@ -129,14 +164,20 @@ func (e *httpError) StatusCode() int {
 }
 
 func (e *httpError) Write(w http.ResponseWriter) {
-	if DefaultErrorWriter == nil {
-		for k, v := range e.headers {
-			w.Header().Set(k, v)
-		}
+	e.WriteRequest(w, nil)
+}
+
+func (e *httpError) WriteRequest(w http.ResponseWriter, r *http.Request) {
+	switch ew := DefaultErrorWriter.(type) {
+	case nil:
+		writeHeaders(w.Header(), e.status, e.Header())
+		_ = redirectLocation(w.Header(), e.status, r)
 		w.WriteHeader(e.status)
-		return
+	case RequestErrorWriter:
+		ew.WriteRequestError(e, w, r)
+	default:
+		ew.WriteError(e, w)
 	}
-	DefaultErrorWriter.WriteError(e, w)
 }
 
 func (e *httpError) Error() string {
@@ -172,20 +213,116 @@ func (e *httpError) AddReason(reason any) HttpError {
 
 func (e *httpError) AddHeaders(hdrs map[string]string) HttpError {
 	for k, v := range hdrs {
-		e.headers[k] = v
+		e.Header().Set(k, v)
 	}
 	return e
 }
 
 func (e *httpError) AddHeader(header string, value string) HttpError {
-	e.headers[header] = value
+	e.Header().Set(header, value)
+	return e
+}
+
+func (e *httpError) SetHeader(header string, value string) HeaderSetter {
+	e.Header().Set(header, value)
+	return e
+}
+
+func (e *httpError) AppendHeader(header string, value string) HeaderSetter {
+	e.Header().Add(header, value)
+	return e
+}
+
+func (e *httpError) DelHeader(header string) HeaderSetter {
+	e.Header().Del(header)
 	return e
 }
 
 func (e *httpError) Headers() map[string]string {
+	result := make(map[string]string, len(e.headers))
+	for k, v := range e.headers {
+		if len(v) > 0 {
+			result[k] = v[0]
+		}
+	}
+	return result
+}
+
+func (e *httpError) Header() http.Header {
 	return e.headers
 }
 
+func (e *httpError) WithRetryAfter(delay time.Duration) RetryAfterSetter {
+	return e.withRetryAfter(RetryAfter{Delay: delay})
+}
+
+func (e *httpError) WithRetryAt(date time.Time) RetryAfterSetter {
+	return e.withRetryAfter(RetryAfter{Date: date})
+}
+
+func (e *httpError) withRetryAfter(ra RetryAfter) RetryAfterSetter {
+	e.retryAfter = ra
+	if ra.IsZero() {
+		e.Header().Del(hdrRetryAfter)
+	} else {
+		e.Header().Set(hdrRetryAfter, ra.String())
+	}
+	return e
+}
+
+func (e *httpError) RetryAfter() (RetryAfter, bool) {
+	return e.retryAfter, !e.retryAfter.IsZero()
+}
+
+func (e *httpError) WithChallenges(challenges ...Challenge) ChallengeSetter {
+	hdr := challengeHeader(e.status)
+	for _, c := range challenges {
+		e.Header().Add(hdr, c.String())
+	}
+	return e
+}
+
+func (e *httpError) Challenges() (result []Challenge) {
+	for _, v := range e.Header().Values(challengeHeader(e.status)) {
+		result = append(result, parseChallenges(v)...)
+	}
+	return result
+}
+
+func (e *httpError) WithAllow(methods ...string) AllowSetter {
+	e.Header().Set(hdrAllow, strings.Join(allowedMethods(methods), ", "))
+	return e
+}
+
+func (e *httpError) Allow() []string {
+	return allowedMethods(e.Header().Values(hdrAllow))
+}
+
+// allowedMethods returns the (trimmed, de-duplicated) methods from Allow header values
+func allowedMethods(values []string) (result []string) {
+	for _, v := range values {
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.TrimSpace(m); m != "" && !slices.Contains(result, m) {
+				result = append(result, m)
+			}
+		}
+	}
+	return result
+}
+
+func (e *httpError) Location() (string, bool) {
+	return headerValue(e.Header(), hdrLocation)
+}
+
+func (e *httpError) ContentRange() (string, bool) {
+	return headerValue(e.Header(), hdrContentRange)
+}
+
+func headerValue(hdrs http.Header, header string) (string, bool) {
+	v := hdrs.Get(header)
+	return v, v != ""
+}
+
 func (e *httpError) StackInfo() StackInfo {
 	return e.stack
 }
@@ -196,7 +333,7 @@ func (e *httpError) Format(f fmt.State, verb rune) {
 		_, _ = fmt.Fprintf(f, "%s", e.message)
 		if f.Flag('+') {
 			if e.cause != nil {
-				_, _ = fmt.Fprintf(f, ": %+v", e.cause)
+				_, _ = fmt.Fprintf(f, ": %v", e.cause)
 			}
 			if len(e.stack) > 0 && DefaultFrameFormatter != nil {
 				_, _ = io.WriteString(f, DefaultFrameFormatter.StartLine())
@@ -204,6 +341,10 @@ func (e *httpError) Format(f fmt.State, verb rune) {
 					_, _ = io.WriteString(f, DefaultFrameFormatter.FrameLine(fr))
 				}
 			}
+			if e.cause != nil {
+				_, _ = io.WriteString(f, "\nCauses:")
+				writeCauseTree(f, e, 0)
+			}
 		} else if e.cause != nil {
 			_, _ = fmt.Fprintf(f, ": %v", e.cause)
 		}
@@ -6,10 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/require"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -210,12 +213,43 @@ func TestError_Format(t *testing.T) {
 		require.Error(t, e)
 		out := fmt.Sprintf("%+v", e)
 		lines := strings.Split(out, "\n")
-		require.Len(t, lines, 3)
+		require.Len(t, lines, 5)
 		require.Equal(t, "fooey: cause", lines[0])
 		require.Equal(t, "Stack:", lines[1])
 		require.True(t, strings.HasPrefix(lines[2], "\tgithub.com/go-andiamo/httperr."))
 		require.Contains(t, lines[2], ".TestError_Format.")
 		require.True(t, strings.HasSuffix(lines[2], fmt.Sprintf(":%d", ln)))
+		require.Equal(t, "Causes:", lines[3])
+		require.Equal(t, "\t*errors.errorString: cause", lines[4])
+	})
+	t.Run("+v with cause tree", func(t *testing.T) {
+		DefaultPackageName = "httperr"
+		defer func() {
+			DefaultPackageName = ""
+		}()
+		inner := NewNotFoundError("not found")
+		e := New(http.StatusBadRequest, "fooey").WithCause(fmt.Errorf("multi: %w, %w", inner, errors.New("other")))
+		out := fmt.Sprintf("%+v", e)
+		lines := strings.Split(out, "\n")
+		require.Len(t, lines, 8)
+		require.Equal(t, "Causes:", lines[3])
+		require.Equal(t, "\t*fmt.wrapErrors: multi: not found, other", lines[4])
+		require.Equal(t, "\t\t*httperr.httpError (404): not found", lines[5])
+		require.True(t, strings.HasPrefix(lines[6], "\t\t\tgithub.com/go-andiamo/httperr."))
+		require.Equal(t, "\t\t*errors.errorString: other", lines[7])
+	})
+	t.Run("+v with formatter cause", func(t *testing.T) {
+		e := New(http.StatusBadRequest, "fooey").WithCause(fmt.Errorf("wrapped: %w", &testFormatterError{}))
+		out := fmt.Sprintf("%+v", e)
+		lines := strings.Split(out, "\n")
+		require.Equal(t, "fooey: wrapped: formatter", lines[0])
+		idx := slices.Index(lines, "Causes:")
+		require.Equal(t, []string{
+			"\t*fmt.wrapError: wrapped: formatter",
+			"\t\t*httperr.testFormatterError: formatter",
+			"\t\t\tdetail line 1",
+			"\t\t\tdetail line 2",
+		}, lines[idx+1:])
 	})
 	t.Run("s", func(t *testing.T) {
 		e := New(http.StatusBadRequest, "fooey")
@@ -258,6 +292,64 @@ func TestError_AddHeader(t *testing.T) {
 	require.Len(t, e.Headers(), 1)
 }
 
+func TestError_AddHeader_Canonical(t *testing.T) {
+	e := New(http.StatusBadRequest, "fooey")
+	_ = e.AddHeader("content-language", "en")
+	_ = e.AddHeader("Content-Language", "fr")
+	require.Len(t, e.Headers(), 1)
+	require.Equal(t, "fr", e.Headers()["Content-Language"])
+}
+
+func TestError_SetHeader(t *testing.T) {
+	e := New(http.StatusBadRequest, "fooey")
+	_ = e.(HeaderSetter).AppendHeader("Link", "<a>").SetHeader("link", "<b>")
+	require.Equal(t, []string{"<b>"}, e.(HeaderSetter).Header().Values("Link"))
+}
+
+func TestError_AppendHeader(t *testing.T) {
+	e := New(http.StatusUnauthorized, "fooey")
+	_ = e.(HeaderSetter).AppendHeader("WWW-Authenticate", "Basic").AppendHeader("www-authenticate", "Bearer")
+	require.Equal(t, []string{"Basic", "Bearer"}, e.(HeaderSetter).Header().Values("WWW-Authenticate"))
+	require.Len(t, e.Headers(), 1)
+	require.Equal(t, "Basic", e.Headers()["Www-Authenticate"])
+}
+
+func TestError_DelHeader(t *testing.T) {
+	e := New(http.StatusBadRequest, "fooey").AddHeader("X-Foo", "bar")
+	require.Len(t, e.Headers(), 1)
+	_ = e.(HeaderSetter).DelHeader("x-foo")
+	require.Empty(t, e.Headers())
+	require.Empty(t, e.(HeaderSetter).Header())
+}
+
+func TestError_Headers_Copy(t *testing.T) {
+	e := New(http.StatusBadRequest, "fooey").AddHeader("X-Foo", "bar")
+	_ = e.(HeaderSetter).AppendHeader("Link", "<a>").AppendHeader("Link", "<b>")
+	hdrs := e.Headers()
+	require.Equal(t, map[string]string{"X-Foo": "bar", "Link": "<a>"}, hdrs)
+	hdrs["X-Foo"] = "changed"
+	delete(hdrs, "Link")
+	require.Equal(t, "bar", e.Headers()["X-Foo"])
+	require.Equal(t, []string{"<a>", "<b>"}, e.(HeaderSetter).Header().Values("Link"))
+}
+
+func TestError_Write_Concurrent(t *testing.T) {
+	e := NewGoneError("gone").AddHeader("X-Foo", "bar")
+	_ = e.Headers()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			e.(RequestWriter).WriteRequest(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			_ = e.Headers()
+			require.Equal(t, "bar", w.Header().Get("X-Foo"))
+		}()
+	}
+	wg.Wait()
+}
+
 func TestError_AddReasons(t *testing.T) {
 	e := New(http.StatusBadRequest, "fooey")
 	require.Error(t, e)
@@ -303,6 +395,47 @@ func TestError_Write(t *testing.T) {
 	})
 }
 
+func TestError_WriteRequest(t *testing.T) {
+	t.Run("with default writer", func(t *testing.T) {
+		e := New(http.StatusNotFound, "fooey")
+		w := httptest.NewRecorder()
+		e.(RequestWriter).WriteRequest(w, httptest.NewRequest(http.MethodHead, "/", nil))
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		require.NotEmpty(t, w.Header().Get("Content-Length"))
+		require.Empty(t, w.Body.Bytes())
+	})
+	t.Run("with non-request writer", func(t *testing.T) {
+		DefaultErrorWriter = &testErrorWriter{}
+		defer func() {
+			DefaultErrorWriter = &errorWriter{}
+		}()
+		e := New(http.StatusNotFound, "fooey")
+		w := httptest.NewRecorder()
+		e.(RequestWriter).WriteRequest(w, httptest.NewRequest(http.MethodHead, "/", nil))
+		require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+	})
+	t.Run("no default writer (304)", func(t *testing.T) {
+		DefaultErrorWriter = nil
+		defer func() {
+			DefaultErrorWriter = &errorWriter{}
+		}()
+		e := NewNotModifiedError("").AddHeaders(map[string]string{"ETag": `"abc"`, "X-Foo": "bar"})
+		w := httptest.NewRecorder()
+		e.(RequestWriter).WriteRequest(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusNotModified, w.Result().StatusCode)
+		require.Equal(t, `"abc"`, w.Header().Get("ETag"))
+		require.Empty(t, w.Header().Get("X-Foo"))
+	})
+}
+
+type testErrorWriter struct{}
+
+var _ ErrorWriter = (*testErrorWriter)(nil)
+
+func (ew *testErrorWriter) WriteError(e error, w http.ResponseWriter) {
+	w.WriteHeader(http.StatusTeapot)
+}
+
 func TestError_MarshalJSON(t *testing.T) {
 	e := New(http.StatusBadRequest, "fooey").
 		WithCause(errors.New("cause")).
@@ -332,7 +465,10 @@ func TestError_MarshalJSON(t *testing.T) {
 		require.Len(t, m, 3)
 		require.Equal(t, "fooey", m[ptyError])
 		require.Len(t, m[ptyReasons], 1)
-		require.Equal(t, "cause", m[ptyCause])
+		causes, ok := m[ptyCause].([]any)
+		require.True(t, ok)
+		require.Len(t, causes, 1)
+		require.Equal(t, map[string]any{"type": "*errors.errorString", "message": "cause"}, causes[0])
 	})
 	t.Run("with stack", func(t *testing.T) {
 		DefaultErrorWriterShowStack = true
@@ -372,3 +508,16 @@ func lineNumber() int {
 	frame, _ := runtime.CallersFrames(pc[:n]).Next()
 	return frame.Line
 }
+
+type testFormatterError struct{}
+
+func (e *testFormatterError) Error() string {
+	return "formatter"
+}
+
+func (e *testFormatterError) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, e.Error())
+	if verb == 'v' && f.Flag('+') {
+		_, _ = io.WriteString(f, "\n\tdetail line 1\n\tdetail line 2")
+	}
+}
@@ -0,0 +1,259 @@
+package httperr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// MaxRequestBodySize is the default maximum size of request body read by DecodeJSON (see WithMaxBodySize)
+var MaxRequestBodySize int64 = 1 << 20
+
+// reason codes used by DecodeJSON
+const (
+	CodeSyntax       = "syntax"
+	CodeType         = "type"
+	CodeUnknownField = "unknownField"
+	CodeTooLarge     = "tooLarge"
+)
+
+// DecodeOption is an option for DecodeJSON
+type DecodeOption func(o *decodeOptions)
+
+type decodeOptions struct {
+	maxBodySize          int64
+	allowUnknownFields   bool
+	allowEmptyBody       bool
+	allowMissingMimeType bool
+	validate             bool
+}
+
+// WithMaxBodySize sets the maximum request body size read by DecodeJSON (default is MaxRequestBodySize)
+func WithMaxBodySize(n int64) DecodeOption {
+	return func(o *decodeOptions) {
+		o.maxBodySize = n
+	}
+}
+
+// AllowUnknownFields allows the request body to contain fields that are not in the decoded type
+func AllowUnknownFields() DecodeOption {
+	return func(o *decodeOptions) {
+		o.allowUnknownFields = true
+	}
+}
+
+// AllowEmptyBody allows an empty request body (the value is left unchanged)
+func AllowEmptyBody() DecodeOption {
+	return func(o *decodeOptions) {
+		o.allowEmptyBody = true
+	}
+}
+
+// AllowMissingContentType allows requests with no Content-Type header to be decoded as json
+func AllowMissingContentType() DecodeOption {
+	return func(o *decodeOptions) {
+		o.allowMissingMimeType = true
+	}
+}
+
+// WithValidation validates the decoded value (using the ValidateTag on struct fields - see ValidateStruct)
+//
+// slices and maps of structs are validated per element (e.g. failures reported as "[0].name") - decoded values
+// that are not structs (e.g. a map[string]any) are not validated
+func WithValidation() DecodeOption {
+	return func(o *decodeOptions) {
+		o.validate = true
+	}
+}
+
+// DecodeJSON decodes the json request body into v
+//
+// returns nil if decoding succeeded, otherwise:
+//   - 415 Unsupported Media Type if the request Content-Type is not json (application/json or +json)
+//   - 413 Request Entity Too Large if the request body exceeds the maximum size
+//   - 400 Bad Request if the body is empty, is not valid json, has unknown fields, has trailing data or has
+//     values of the wrong type - with a reason (including the JSON pointer and byte offset, where known)
+//   - 422 Unprocessable Entity if the WithValidation option is used and the decoded value is invalid
+//   - 500 Internal Server Error if v cannot be decoded into (e.g. is not a pointer)
+func DecodeJSON(r *http.Request, v any, opts ...DecodeOption) HttpError {
+	o := &decodeOptions{
+		maxBodySize: MaxRequestBodySize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := decodeJSON(r, v, o); err != nil {
+		return err.newError(getStackInfo())
+	}
+	return nil
+}
+
+type requestError struct {
+	status    int
+	message   string
+	reasons   []Reason
+	cause     error
+	validator *Validator
+}
+
+func (e *requestError) newError(si StackInfo) HttpError {
+	if e.validator != nil {
+		return e.validator.newError(si)
+	}
+	result := newError(e.status, e.message, e.cause, si)
+	for _, r := range e.reasons {
+		result.AddReasons(r)
+	}
+	return result
+}
+
+func decodeJSON(r *http.Request, v any, o *decodeOptions) *requestError {
+	if ct := r.Header.Get(hdrContentType); !isJsonContentType(ct) && !(ct == "" && o.allowMissingMimeType) {
+		return &requestError{
+			status:  http.StatusUnsupportedMediaType,
+			message: "unsupported content type",
+			reasons: []Reason{{Message: "content type must be " + applicationJson, Value: ct}},
+		}
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return emptyBody(o)
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, o.maxBodySize))
+	if !o.allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return emptyBody(o)
+		}
+		return translateDecodeError(err)
+	}
+	offset := dec.InputOffset()
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return translateDecodeError(err)
+		}
+		return &requestError{
+			status:  http.StatusBadRequest,
+			message: "request body must contain a single json value",
+			reasons: []Reason{{Code: CodeSyntax, Message: "unexpected data after json value", Meta: map[string]any{"offset": offset}}},
+		}
+	}
+	if o.validate {
+		// only structs (or slices/maps of structs) are validated - other decoded values have no validation tags
+		rv := reflect.ValueOf(v)
+		if val := Validate(); !val.walkValue("", walkKindOf(rv.Type()), rv).Valid() {
+			return &requestError{validator: val}
+		}
+	}
+	return nil
+}
+
+func emptyBody(o *decodeOptions) *requestError {
+	if o.allowEmptyBody {
+		return nil
+	}
+	return &requestError{
+		status:  http.StatusBadRequest,
+		message: "request body is empty",
+	}
+}
+
+func translateDecodeError(err error) *requestError {
+	var mbe *http.MaxBytesError
+	var se *json.SyntaxError
+	var ute *json.UnmarshalTypeError
+	var iue *json.InvalidUnmarshalError
+	switch {
+	case errors.As(err, &mbe):
+		return &requestError{
+			status:  http.StatusRequestEntityTooLarge,
+			message: "request body too large",
+			reasons: []Reason{{Code: CodeTooLarge, Message: fmt.Sprintf("request body must not exceed %d bytes", mbe.Limit), Meta: map[string]any{"limit": mbe.Limit}}},
+		}
+	case errors.As(err, &se):
+		return &requestError{
+			status:  http.StatusBadRequest,
+			message: "malformed json",
+			reasons: []Reason{{Code: CodeSyntax, Message: se.Error(), Meta: map[string]any{"offset": se.Offset}}},
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &requestError{
+			status:  http.StatusBadRequest,
+			message: "malformed json",
+			reasons: []Reason{{Code: CodeSyntax, Message: "unexpected end of json input"}},
+		}
+	case errors.As(err, &ute):
+		return &requestError{
+			status:  http.StatusBadRequest,
+			message: "invalid json value",
+			reasons: []Reason{{
+				Code:    CodeType,
+				Message: "must be " + jsonTypeName(ute.Type),
+				Field:   ute.Field,
+				Pointer: fieldPointer(ute.Field),
+				Value:   ute.Value,
+				Meta:    map[string]any{"offset": ute.Offset},
+			}},
+		}
+	case errors.As(err, &iue):
+		return &requestError{
+			status: http.StatusInternalServerError,
+			cause:  err,
+		}
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		// the error only has the field name (not its path) - so there is no pointer to the field
+		name = strings.Trim(name, `"`)
+		return &requestError{
+			status:  http.StatusBadRequest,
+			message: "unknown field",
+			reasons: []Reason{{
+				Code:    CodeUnknownField,
+				Message: "unknown field",
+				Field:   name,
+			}},
+		}
+	}
+	return &requestError{
+		status: http.StatusBadRequest,
+		cause:  err,
+	}
+}
+
+func fieldPointer(field string) string {
+	if field == "" {
+		return ""
+	}
+	var sb strings.Builder
+	for _, part := range strings.Split(field, ".") {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(part))
+	}
+	return sb.String()
+}
+
+func jsonTypeName(t reflect.Type) string {
+	t = derefType(t)
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return t.String()
+}
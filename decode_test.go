@@ -0,0 +1,209 @@
+package httperr
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testDecodeBody struct {
+	Name    string       `json:"name" httperr:"required"`
+	Age     int          `json:"age"`
+	Address *testAddress `json:"address"`
+}
+
+func newJsonRequest(body string, contentType string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set(hdrContentType, contentType)
+	}
+	return r
+}
+
+func TestDecodeJSON(t *testing.T) {
+	v := testDecodeBody{}
+	err := DecodeJSON(newJsonRequest(`{"name":"bob","age":21}`, "application/json; charset=utf-8"), &v)
+	require.Nil(t, err)
+	require.Equal(t, testDecodeBody{Name: "bob", Age: 21}, v)
+
+	v = testDecodeBody{}
+	err = DecodeJSON(newJsonRequest(` {"name":"bob"} `+"\n", "application/merge-patch+json"), &v)
+	require.Nil(t, err)
+	require.Equal(t, "bob", v.Name)
+}
+
+func TestDecodeJSON_Errors(t *testing.T) {
+	testCases := []struct {
+		body        string
+		contentType string
+		opts        []DecodeOption
+		status      int
+		message     string
+		reason      *Reason
+	}{
+		{
+			body:        `{}`,
+			contentType: "text/plain",
+			status:      http.StatusUnsupportedMediaType,
+			message:     "unsupported content type",
+			reason:      &Reason{Message: "content type must be application/json", Value: "text/plain"},
+		},
+		{
+			body:    `{}`,
+			status:  http.StatusUnsupportedMediaType,
+			message: "unsupported content type",
+			reason:  &Reason{Message: "content type must be application/json", Value: ""},
+		},
+		{
+			body:    ``,
+			status:  http.StatusBadRequest,
+			message: "request body is empty",
+		},
+		{
+			body:    `{"name":"` + strings.Repeat("x", 100) + `"}`,
+			opts:    []DecodeOption{WithMaxBodySize(50)},
+			status:  http.StatusRequestEntityTooLarge,
+			message: "request body too large",
+			reason:  &Reason{Code: CodeTooLarge, Message: "request body must not exceed 50 bytes", Meta: map[string]any{"limit": int64(50)}},
+		},
+		{
+			body:    `{"name":"bob",}`,
+			status:  http.StatusBadRequest,
+			message: "malformed json",
+			reason:  &Reason{Code: CodeSyntax, Message: "invalid character '}' looking for beginning of object key string", Meta: map[string]any{"offset": int64(15)}},
+		},
+		{
+			body:    `{"name":"bob"`,
+			status:  http.StatusBadRequest,
+			message: "malformed json",
+			reason:  &Reason{Code: CodeSyntax, Message: "unexpected end of json input"},
+		},
+		{
+			body:    `{"name":"bob","address":{"zip":123}}`,
+			status:  http.StatusBadRequest,
+			message: "invalid json value",
+			reason: &Reason{Code: CodeType, Message: "must be a string", Field: "address.zip", Pointer: "/address/zip", Value: "number",
+				Meta: map[string]any{"offset": int64(34)}},
+		},
+		{
+			body:    `{"name":"bob","foo":1}`,
+			status:  http.StatusBadRequest,
+			message: "unknown field",
+			reason:  &Reason{Code: CodeUnknownField, Message: "unknown field", Field: "foo"},
+		},
+		{
+			body:    `{"name":"bob","address":{"foo":1}}`,
+			status:  http.StatusBadRequest,
+			message: "unknown field",
+			reason:  &Reason{Code: CodeUnknownField, Message: "unknown field", Field: "foo"},
+		},
+		{
+			body:    `{"name":"bob"} {}`,
+			status:  http.StatusBadRequest,
+			message: "request body must contain a single json value",
+			reason:  &Reason{Code: CodeSyntax, Message: "unexpected data after json value", Meta: map[string]any{"offset": int64(14)}},
+		},
+		{
+			body:    `{"name":"bob"} x`,
+			status:  http.StatusBadRequest,
+			message: "request body must contain a single json value",
+			reason:  &Reason{Code: CodeSyntax, Message: "unexpected data after json value", Meta: map[string]any{"offset": int64(14)}},
+		},
+	}
+	for i, tc := range testCases {
+		ct := tc.contentType
+		if ct == "" && tc.status != http.StatusUnsupportedMediaType {
+			ct = applicationJson
+		}
+		v := testDecodeBody{}
+		err := DecodeJSON(newJsonRequest(tc.body, ct), &v, tc.opts...)
+		require.Error(t, err, i)
+		require.Equal(t, tc.status, err.StatusCode(), i)
+		require.Equal(t, tc.message, err.Error(), i)
+		if tc.reason != nil {
+			require.Equal(t, []any{*tc.reason}, err.Reasons(), i)
+		} else {
+			require.Empty(t, err.Reasons(), i)
+		}
+		require.Contains(t, err.StackInfo()[0].Function, "TestDecodeJSON_Errors", i)
+	}
+}
+
+func TestDecodeJSON_Options(t *testing.T) {
+	v := testDecodeBody{}
+	err := DecodeJSON(newJsonRequest(`{"name":"bob","foo":1}`, ""), &v, AllowUnknownFields(), AllowMissingContentType())
+	require.Nil(t, err)
+	require.Equal(t, "bob", v.Name)
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(hdrContentType, applicationJson)
+	err = DecodeJSON(r, &v)
+	require.Error(t, err)
+	require.Equal(t, "request body is empty", err.Error())
+	require.Nil(t, DecodeJSON(r, &v, AllowEmptyBody()))
+	require.Nil(t, DecodeJSON(newJsonRequest(``, applicationJson), &v, AllowEmptyBody()))
+}
+
+func TestDecodeJSON_WithValidation(t *testing.T) {
+	v := testDecodeBody{}
+	err := DecodeJSON(newJsonRequest(`{"age":1,"address":{"street":""}}`, applicationJson), &v, WithValidation())
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, err.StatusCode())
+	require.Len(t, err.Reasons(), 2)
+	require.Equal(t, "/address/street", err.Reasons()[1].(Reason).Pointer)
+	require.Contains(t, err.StackInfo()[0].Function, "TestDecodeJSON_WithValidation")
+
+	v = testDecodeBody{}
+	require.Nil(t, DecodeJSON(newJsonRequest(`{"name":"bob"}`, applicationJson), &v, WithValidation()))
+}
+
+func TestDecodeJSON_WithValidation_NonStruct(t *testing.T) {
+	var items []testDecodeBody
+	err := DecodeJSON(newJsonRequest(`[{"name":"bob"},{"age":1}]`, applicationJson), &items, WithValidation())
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, err.StatusCode())
+	require.Equal(t, "[1].name", err.Reasons()[0].(Reason).Field)
+	require.Equal(t, "/1/name", err.Reasons()[0].(Reason).Pointer)
+
+	var byKey map[string]*testDecodeBody
+	err = DecodeJSON(newJsonRequest(`{"a":{"name":"bob"},"b":{}}`, applicationJson), &byKey, WithValidation())
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, err.StatusCode())
+	require.Equal(t, "/b/name", err.Reasons()[0].(Reason).Pointer)
+
+	var m map[string]any
+	require.Nil(t, DecodeJSON(newJsonRequest(`{"a":1}`, applicationJson), &m, WithValidation()))
+	var ints []int
+	require.Nil(t, DecodeJSON(newJsonRequest(`[1,2]`, applicationJson), &ints, WithValidation()))
+}
+
+func TestDecodeJSON_InternalErrors(t *testing.T) {
+	err := DecodeJSON(newJsonRequest(`{}`, applicationJson), testDecodeBody{})
+	require.Error(t, err)
+	require.Equal(t, http.StatusInternalServerError, err.StatusCode())
+	require.NotNil(t, err.Unwrap())
+
+	r := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(errorReader{}))
+	r.Header.Set(hdrContentType, applicationJson)
+	err = DecodeJSON(r, &testDecodeBody{})
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, err.StatusCode())
+	require.True(t, errors.Is(err, errRead))
+}
+
+var errRead = errors.New("read failed")
+
+type errorReader struct{}
+
+func (errorReader) Read(p []byte) (int, error) {
+	return 0, errRead
+}
+
+func TestFieldPointer(t *testing.T) {
+	require.Equal(t, "", fieldPointer(""))
+	require.Equal(t, "/a/b~1c", fieldPointer("a.b/c"))
+}
@@ -0,0 +1,292 @@
+package httperr
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// the internal default error writer (and HttpError json marshalling) encodes response bodies
+// by hand - appending to pooled buffers with a deterministic property order:
+//
+// error, status, timestamp, reasons, retryAfter, retryable, cause, stack then any ResponseFormat.Fields (sorted by name and
+// skipping any with the same name as a property already written)
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) <= 64*1024 {
+		*b = (*b)[:0]
+		bufferPool.Put(b)
+	}
+}
+
+func (rf *ResponseFormat) appendBody(dst []byte, status int, msg string, he HttpError, err error, envelope bool) ([]byte, error) {
+	enveloped := envelope && rf != nil && rf.Envelope != ""
+	if enveloped {
+		dst = append(dst, '{')
+		dst = appendString(dst, rf.Envelope)
+		dst = append(dst, ':')
+	}
+	// emitted are the property names written so far - Fields with the same names are skipped (so that keys are not duplicated)
+	emitted := make([]string, 0, 8)
+	name := func(property string) string {
+		n := rf.Name(property)
+		emitted = append(emitted, n)
+		return n
+	}
+	dst = append(dst, '{')
+	dst = appendName(dst, name(PropertyError), true)
+	dst = appendString(dst, msg)
+	if rf != nil && rf.IncludeStatus {
+		dst = appendName(dst, name(PropertyStatus), false)
+		dst = strconv.AppendInt(dst, int64(status), 10)
+	}
+	if rf != nil && rf.IncludeTimestamp {
+		layout := rf.TimestampFormat
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		dst = appendName(dst, name(PropertyTimestamp), false)
+		dst = append(dst, '"')
+		dst = timeNow().AppendFormat(dst, layout)
+		dst = append(dst, '"')
+	}
+	var encErr error
+	if he != nil {
+		if reasons := he.Reasons(); len(reasons) > 0 {
+			dst = appendName(dst, name(PropertyReasons), false)
+			if dst, encErr = appendValues(dst, reasons); encErr != nil {
+				return dst, encErr
+			}
+		}
+	}
+	if he != nil {
+		if rae, ok := he.(RetryAfterError); ok {
+			if ra, ok := rae.RetryAfter(); ok {
+				dst = appendName(dst, name(PropertyRetryAfter), false)
+				dst = strconv.AppendInt(dst, ra.Seconds(), 10)
+			}
+		}
+		if retryable := IsRetryable(he); retryable || slices.Contains(DefaultRetryableStatuses, status) {
+			dst = appendName(dst, name(PropertyRetryable), false)
+			dst = strconv.AppendBool(dst, retryable)
+		}
+	}
+	if DefaultErrorWriterShowCause {
+		if causes := causeEntries(err, DefaultErrorWriterShowStack, 0); len(causes) > 0 {
+			dst = appendName(dst, name(PropertyCause), false)
+			dst = appendCauses(dst, causes)
+		}
+	}
+	if he != nil && DefaultErrorWriterShowStack {
+		if stack := he.StackInfo(); len(stack) > 0 {
+			dst = appendName(dst, name(PropertyStack), false)
+			dst = appendStack(dst, stack)
+		}
+	}
+	if rf != nil && len(rf.Fields) > 0 {
+		names := make([]string, 0, len(rf.Fields))
+		for k := range rf.Fields {
+			if !slices.Contains(emitted, k) {
+				names = append(names, k)
+			}
+		}
+		slices.Sort(names)
+		for _, k := range names {
+			dst = appendName(dst, k, false)
+			if dst, encErr = appendValue(dst, rf.Fields[k]); encErr != nil {
+				return dst, encErr
+			}
+		}
+	}
+	dst = append(dst, '}')
+	if enveloped {
+		dst = append(dst, '}')
+	}
+	return dst, nil
+}
+
+func (rf *ResponseFormat) appendFallbackBody(dst []byte, status int, msg string) []byte {
+	enveloped := rf != nil && rf.Envelope != ""
+	if enveloped {
+		dst = append(dst, '{')
+		dst = appendString(dst, rf.Envelope)
+		dst = append(dst, ':')
+	}
+	dst = append(dst, '{')
+	dst = appendName(dst, rf.Name(PropertyError), true)
+	dst = appendString(dst, msg)
+	if rf != nil && rf.IncludeStatus {
+		dst = appendName(dst, rf.Name(PropertyStatus), false)
+		dst = strconv.AppendInt(dst, int64(status), 10)
+	}
+	dst = append(dst, '}')
+	if enveloped {
+		dst = append(dst, '}')
+	}
+	return dst
+}
+
+func appendName(dst []byte, name string, first bool) []byte {
+	if !first {
+		dst = append(dst, ',')
+	}
+	dst = appendString(dst, name)
+	return append(dst, ':')
+}
+
+func appendValues(dst []byte, values []any) (result []byte, err error) {
+	dst = append(dst, '[')
+	for i, v := range values {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if dst, err = appendValue(dst, v); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, ']'), nil
+}
+
+func appendValue(dst []byte, v any) ([]byte, error) {
+	switch vt := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case string:
+		return appendString(dst, vt), nil
+	case bool:
+		return strconv.AppendBool(dst, vt), nil
+	case int:
+		return strconv.AppendInt(dst, int64(vt), 10), nil
+	case int64:
+		return strconv.AppendInt(dst, vt, 10), nil
+	case Reason:
+		return appendReason(dst, vt)
+	case *Reason:
+		if vt != nil {
+			return appendReason(dst, *vt)
+		}
+		return append(dst, "null"...), nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, data...), nil
+}
+
+func appendStack(dst []byte, stack StackInfo) []byte {
+	dst = append(dst, '[')
+	for i, f := range stack {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, '"')
+		dst = appendStringContent(dst, f.Function)
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, int64(f.Line), 10)
+		dst = append(dst, '"')
+	}
+	return append(dst, ']')
+}
+
+func appendCauses(dst []byte, causes []CauseEntry) []byte {
+	dst = append(dst, '[')
+	for i, c := range causes {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `{"type":`...)
+		dst = appendString(dst, c.Type)
+		dst = append(dst, `,"message":`...)
+		dst = appendString(dst, c.Message)
+		if c.Status != 0 {
+			dst = append(dst, `,"status":`...)
+			dst = strconv.AppendInt(dst, int64(c.Status), 10)
+		}
+		if len(c.Stack) > 0 {
+			dst = append(dst, `,"stack":[`...)
+			for j, s := range c.Stack {
+				if j > 0 {
+					dst = append(dst, ',')
+				}
+				dst = appendString(dst, s)
+			}
+			dst = append(dst, ']')
+		}
+		if len(c.Causes) > 0 {
+			dst = append(dst, `,"causes":`...)
+			dst = appendCauses(dst, c.Causes)
+		}
+		dst = append(dst, '}')
+	}
+	return append(dst, ']')
+}
+
+func appendString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	dst = appendStringContent(dst, s)
+	return append(dst, '"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendStringContent appends the json escaped string content - escaping the same as encoding/json
+// (i.e. including HTML characters, invalid UTF-8 and U+2028/U+2029)
+func appendStringContent(dst []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	return append(dst, s[start:]...)
+}
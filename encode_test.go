@@ -0,0 +1,86 @@
+package httperr
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestAppendString(t *testing.T) {
+	testCases := []string{
+		"",
+		"plain",
+		`quote " and backslash \`,
+		"control \n\r\t\x00\x1f",
+		"html <script>&</script>",
+		"unicode héllo 世界",
+		"invalid \xff utf-8",
+		"separators \u2028 \u2029",
+	}
+	for _, tc := range testCases {
+		t.Run(tc, func(t *testing.T) {
+			expect, err := json.Marshal(tc)
+			require.NoError(t, err)
+			require.Equal(t, string(expect), string(appendString(nil, tc)))
+		})
+	}
+}
+
+func TestAppendValue(t *testing.T) {
+	testCases := []any{nil, "str", true, 1, int64(2), 1.5, map[string]any{"a": 1}, testReason{"foo", "bar"}}
+	for _, tc := range testCases {
+		expect, err := json.Marshal(tc)
+		require.NoError(t, err)
+		actual, err := appendValue(nil, tc)
+		require.NoError(t, err)
+		require.Equal(t, string(expect), string(actual))
+	}
+	_, err := appendValue(nil, make(chan int))
+	require.Error(t, err)
+}
+
+func TestResponseFormat_AppendBody(t *testing.T) {
+	DefaultErrorWriterShowCause = true
+	defer func() {
+		DefaultErrorWriterShowCause = false
+	}()
+	rf := &ResponseFormat{
+		Naming:        NamingCamel,
+		IncludeStatus: true,
+		Fields:        map[string]any{"z": 1, "a": "first"},
+	}
+	e := NewConflictError("whoops").AddReasons("foo", testReason{"bar", "baz"}).
+		WithCause(errors.Join(NewNotFoundError("not found"), errors.New("other")))
+	data, err := rf.appendBody(nil, e.StatusCode(), e.Error(), e, e, false)
+	require.NoError(t, err)
+	require.Equal(t, `{"error":"whoops","status":409,"reasons":["foo",{"property":"bar","reason":"baz"}],`+
+		`"cause":[{"type":"*errors.joinError","message":"not found\nother","causes":[`+
+		`{"type":"*httperr.httpError","message":"not found","status":404},`+
+		`{"type":"*errors.errorString","message":"other"}]}],"a":"first","z":1}`, string(data))
+	require.True(t, json.Valid(data))
+}
+
+func TestResponseFormat_AppendBody_FieldsDuplicateProperties(t *testing.T) {
+	rf := &ResponseFormat{
+		Naming:        NamingCamel,
+		IncludeStatus: true,
+		Fields:        map[string]any{"status": "ok", "error": "x", "reasons": "x", "version": "v1"},
+	}
+	data, err := rf.appendBody(nil, http.StatusConflict, "whoops", nil, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, `{"error":"whoops","status":409,"reasons":"x","version":"v1"}`, string(data))
+	require.True(t, json.Valid(data))
+}
+
+func TestBufferPool(t *testing.T) {
+	b := getBuffer()
+	*b = append(*b, "something"...)
+	putBuffer(b)
+	b = getBuffer()
+	require.Empty(t, *b)
+	putBuffer(b)
+	big := make([]byte, 0, 128*1024)
+	putBuffer(&big)
+}
@@ -3,6 +3,7 @@ package httperr
 import (
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // NewBadRequestError creates a new 400 Bad Request error
@@ -33,6 +34,15 @@ func NewUnauthorizedErrorf(format string, a ...any) HttpError {
 	return newError(http.StatusUnauthorized, fmt.Sprintf(format, a...), nil, getStackInfo())
 }
 
+// NewUnauthorizedChallengeError creates a new 401 Unauthorized error with authentication challenges
+//
+// each challenge is added as a WWW-Authenticate header
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-401-unauthorized
+func NewUnauthorizedChallengeError(msg string, challenges ...Challenge) HttpError {
+	return newError(http.StatusUnauthorized, msg, nil, getStackInfo()).WithChallenges(challenges...)
+}
+
 // NewPaymentRequiredError creates a new 402 Payment Required error
 //
 // see https://www.rfc-editor.org/rfc/rfc9110.html#name-402-payment-required
@@ -89,6 +99,15 @@ func NewMethodNotAllowedErrorf(format string, a ...any) HttpError {
 	return newError(http.StatusMethodNotAllowed, fmt.Sprintf(format, a...), nil, getStackInfo())
 }
 
+// NewMethodNotAllowedAllowError creates a new 405 Method Not Allowed error with the allowed methods
+//
+// the allowed methods are added as the (required) Allow header
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-405-method-not-allowed
+func NewMethodNotAllowedAllowError(msg string, allowed ...string) HttpError {
+	return newError(http.StatusMethodNotAllowed, msg, nil, getStackInfo()).WithAllow(allowed...)
+}
+
 // NewNotAcceptableError creates a new 406 Not Acceptable error
 //
 // see https://www.rfc-editor.org/rfc/rfc9110.html#name-406-not-acceptable
@@ -117,6 +136,15 @@ func NewProxyAuthRequiredErrorf(format string, a ...any) HttpError {
 	return newError(http.StatusProxyAuthRequired, fmt.Sprintf(format, a...), nil, getStackInfo())
 }
 
+// NewProxyAuthRequiredChallengeError creates a new 407 Proxy Authentication Required error with authentication challenges
+//
+// each challenge is added as a Proxy-Authenticate header
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-407-proxy-authentication-re
+func NewProxyAuthRequiredChallengeError(msg string, challenges ...Challenge) HttpError {
+	return newError(http.StatusProxyAuthRequired, msg, nil, getStackInfo()).WithChallenges(challenges...)
+}
+
 // NewRequestTimeoutError creates a new 408 Request Timeout error
 //
 // see https://www.rfc-editor.org/rfc/rfc9110.html#name-408-request-timeout
@@ -243,6 +271,15 @@ func NewRequestedRangeNotSatisfiableErrorf(format string, a ...any) HttpError {
 	return newError(http.StatusRequestedRangeNotSatisfiable, fmt.Sprintf(format, a...), nil, getStackInfo())
 }
 
+// NewRequestedRangeNotSatisfiableSizeError creates a new 416 Requested Range Not Satisfiable error with the
+// Content-Range header set to the current length of the representation (i.e. "bytes */<size>")
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-416-range-not-satisfiable
+func NewRequestedRangeNotSatisfiableSizeError(msg string, size int64) HttpError {
+	return newError(http.StatusRequestedRangeNotSatisfiable, msg, nil, getStackInfo()).
+		SetHeader(hdrContentRange, unsatisfiedContentRange(size))
+}
+
 // NewExpectationFailedError creates a new 417 Expectation Failed error
 //
 // see https://www.rfc-editor.org/rfc/rfc9110.html#name-417-expectation-failed
@@ -369,6 +406,13 @@ func NewTooManyRequestsErrorf(format string, a ...any) HttpError {
 	return newError(http.StatusTooManyRequests, fmt.Sprintf(format, a...), nil, getStackInfo())
 }
 
+// NewTooManyRequestsRetryError creates a new 429 Too Many Requests error with a Retry-After delay
+//
+// see https://datatracker.ietf.org/doc/html/rfc6585#section-4
+func NewTooManyRequestsRetryError(msg string, after time.Duration) HttpError {
+	return newError(http.StatusTooManyRequests, msg, nil, getStackInfo()).WithRetryAfter(after)
+}
+
 // NewRequestHeaderFieldsTooLargeError creates a new 431 Request Header Fields Too Large error
 //
 // see https://datatracker.ietf.org/doc/html/rfc6585#section-5
@@ -425,6 +469,13 @@ func NewServiceUnavailableError(msg string, cause error) HttpError {
 	return newError(http.StatusServiceUnavailable, msg, cause, getStackInfo())
 }
 
+// NewServiceUnavailableRetryError creates a new 503 Service Unavailable error with a Retry-After delay
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-503-service-unavailable
+func NewServiceUnavailableRetryError(msg string, cause error, after time.Duration) HttpError {
+	return newError(http.StatusServiceUnavailable, msg, cause, getStackInfo()).WithRetryAfter(after)
+}
+
 // NewGatewayTimeoutError creates a new 504 Gateway Timeout error
 //
 // see https://www.rfc-editor.org/rfc/rfc9110.html#name-504-gateway-timeout
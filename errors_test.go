@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestNewBadRequestError(t *testing.T) {
@@ -31,6 +32,14 @@ func TestNewUnauthorizedErrorf(t *testing.T) {
 	require.Equal(t, "something 1", e.Error())
 }
 
+func TestNewUnauthorizedChallengeError(t *testing.T) {
+	e := NewUnauthorizedChallengeError("", BasicChallenge("api"))
+	require.Equal(t, http.StatusUnauthorized, e.StatusCode())
+	require.Equal(t, "Unauthorized", e.Error())
+	require.Len(t, e.(ChallengeError).Challenges(), 1)
+	require.Equal(t, `Basic realm="api", charset="UTF-8"`, e.(HeaderSetter).Header().Get("WWW-Authenticate"))
+}
+
 func TestNewPaymentRequiredError(t *testing.T) {
 	e := NewPaymentRequiredError("")
 	require.Equal(t, http.StatusPaymentRequired, e.StatusCode())
@@ -79,6 +88,14 @@ func TestNewMethodNotAllowedErrorf(t *testing.T) {
 	require.Equal(t, "something 1", e.Error())
 }
 
+func TestNewMethodNotAllowedAllowError(t *testing.T) {
+	e := NewMethodNotAllowedAllowError("", http.MethodGet, http.MethodPost)
+	require.Equal(t, http.StatusMethodNotAllowed, e.StatusCode())
+	require.Equal(t, "Method Not Allowed", e.Error())
+	require.Equal(t, []string{"GET", "POST"}, e.(AllowError).Allow())
+	require.Equal(t, "GET, POST", e.(HeaderSetter).Header().Get("Allow"))
+}
+
 func TestNewNotAcceptableError(t *testing.T) {
 	e := NewNotAcceptableError("")
 	require.Equal(t, http.StatusNotAcceptable, e.StatusCode())
@@ -103,6 +120,14 @@ func TestNewProxyAuthRequiredErrorf(t *testing.T) {
 	require.Equal(t, "something 1", e.Error())
 }
 
+func TestNewProxyAuthRequiredChallengeError(t *testing.T) {
+	e := NewProxyAuthRequiredChallengeError("", BasicChallenge("proxy"))
+	require.Equal(t, http.StatusProxyAuthRequired, e.StatusCode())
+	require.Equal(t, "Proxy Authentication Required", e.Error())
+	require.Len(t, e.(ChallengeError).Challenges(), 1)
+	require.Equal(t, `Basic realm="proxy", charset="UTF-8"`, e.(HeaderSetter).Header().Get("Proxy-Authenticate"))
+}
+
 func TestNewRequestTimeoutError(t *testing.T) {
 	e := NewRequestTimeoutError("")
 	require.Equal(t, http.StatusRequestTimeout, e.StatusCode())
@@ -211,6 +236,17 @@ func TestNewRequestedRangeNotSatisfiableErrorf(t *testing.T) {
 	require.Equal(t, "something 1", e.Error())
 }
 
+func TestNewRequestedRangeNotSatisfiableSizeError(t *testing.T) {
+	e := NewRequestedRangeNotSatisfiableSizeError("", 1234)
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, e.StatusCode())
+	require.Equal(t, "Requested Range Not Satisfiable", e.Error())
+	var cre ContentRangeError
+	require.True(t, errors.As(e, &cre))
+	cr, ok := cre.ContentRange()
+	require.True(t, ok)
+	require.Equal(t, "bytes */1234", cr)
+}
+
 func TestNewExpectationFailedError(t *testing.T) {
 	e := NewExpectationFailedError("")
 	require.Equal(t, http.StatusExpectationFailed, e.StatusCode())
@@ -319,6 +355,16 @@ func TestNewTooManyRequestsErrorf(t *testing.T) {
 	require.Equal(t, "something 1", e.Error())
 }
 
+func TestNewTooManyRequestsRetryError(t *testing.T) {
+	e := NewTooManyRequestsRetryError("", time.Minute)
+	require.Equal(t, http.StatusTooManyRequests, e.StatusCode())
+	require.Equal(t, "Too Many Requests", e.Error())
+	require.Equal(t, "60", e.(HeaderSetter).Header().Get("Retry-After"))
+	ra, ok := e.(RetryAfterError).RetryAfter()
+	require.True(t, ok)
+	require.Equal(t, time.Minute, ra.Delay)
+}
+
 func TestNewRequestHeaderFieldsTooLargeError(t *testing.T) {
 	e := NewRequestHeaderFieldsTooLargeError("")
 	require.Equal(t, http.StatusRequestHeaderFieldsTooLarge, e.StatusCode())
@@ -370,6 +416,14 @@ func TestNewServiceUnavailableError(t *testing.T) {
 	require.Error(t, errors.Unwrap(e))
 }
 
+func TestNewServiceUnavailableRetryError(t *testing.T) {
+	e := NewServiceUnavailableRetryError("", errors.New("cause"), 5*time.Second)
+	require.Equal(t, http.StatusServiceUnavailable, e.StatusCode())
+	require.Equal(t, "Service Unavailable", e.Error())
+	require.Error(t, errors.Unwrap(e))
+	require.Equal(t, "5", e.(HeaderSetter).Header().Get("Retry-After"))
+}
+
 func TestNewGatewayTimeoutError(t *testing.T) {
 	e := NewGatewayTimeoutError("")
 	require.Equal(t, http.StatusGatewayTimeout, e.StatusCode())
@@ -0,0 +1,120 @@
+package httperr
+
+import (
+	"strings"
+	"time"
+)
+
+// NamingPolicy determines how response body property names are derived from logical property names
+type NamingPolicy int
+
+const (
+	// NamingDefault derives property names as camel case with a "$" prefix (e.g. "$error", "$retryAfter")
+	NamingDefault NamingPolicy = iota
+	// NamingCamel derives property names as camel case (e.g. "error", "retryAfter")
+	NamingCamel
+	// NamingSnake derives property names as snake case (e.g. "error", "retry_after")
+	NamingSnake
+)
+
+// logical property names used by ResponseFormat
+const (
+	PropertyError     = "error"
+	PropertyReasons   = "reasons"
+	PropertyCause     = "cause"
+	PropertyStack     = "stack"
+	PropertyStatus    = "status"
+	PropertyTimestamp = "timestamp"
+	// PropertyRetryAfter is the retry after (in seconds) - only written for errors with a Retry-After
+	PropertyRetryAfter = "retryAfter"
+	// PropertyRetryable is the retryable hint - only written for retryable errors (or errors whose
+	// status is normally retryable but have been overridden as not retryable)
+	PropertyRetryable = "retryable"
+)
+
+// ResponseFormat determines the shape of the response body json written by the DefaultErrorWriter
+// (and the json produced by marshalling a HttpError)
+//
+// for example, to produce legacy bodies such as `{"error":{"message":"Not Found","status":404}}`...
+//
+//	httperr.DefaultResponseFormat = &httperr.ResponseFormat{
+//		Naming:        httperr.NamingCamel,
+//		Envelope:      "error",
+//		Properties:    map[string]string{httperr.PropertyError: "message"},
+//		IncludeStatus: true,
+//	}
+type ResponseFormat struct {
+	// Naming is the naming policy used to derive property names (that are not overridden by Properties)
+	Naming NamingPolicy
+	// Properties overrides property names - keyed by the logical property name (e.g. PropertyError)
+	Properties map[string]string
+	// Envelope, if non-empty, wraps the response body in an object with this property name
+	//
+	// the envelope is not used when marshalling a HttpError
+	Envelope string
+	// IncludeStatus determines whether the status code is always included in the body
+	IncludeStatus bool
+	// IncludeTimestamp determines whether the time of writing is always included in the body
+	IncludeTimestamp bool
+	// TimestampFormat is the layout used for the timestamp (if empty, time.RFC3339 is used)
+	TimestampFormat string
+	// Fields are additional fields always included in the body
+	//
+	// fields with the same name as a property written in the body (e.g. "status") are not included
+	Fields map[string]any
+}
+
+// DefaultResponseFormat is the response format used by the DefaultErrorWriter and when marshalling HttpError
+//
+// if this is set to nil, the default format (i.e. "$error", "$reasons" etc.) is used
+var DefaultResponseFormat = &ResponseFormat{}
+
+var timeNow = time.Now
+
+// Name returns the property name for the supplied logical property name (e.g. PropertyError)
+func (rf *ResponseFormat) Name(property string) string {
+	if rf == nil {
+		return (&ResponseFormat{}).Name(property)
+	}
+	if n, ok := rf.Properties[property]; ok && n != "" {
+		return n
+	}
+	switch rf.Naming {
+	case NamingCamel:
+		return property
+	case NamingSnake:
+		return toSnake(property)
+	}
+	if n, ok := defaultNames[property]; ok {
+		return n
+	}
+	return "$" + property
+}
+
+var defaultNames = map[string]string{
+	PropertyError:      "$" + PropertyError,
+	PropertyReasons:    "$" + PropertyReasons,
+	PropertyCause:      "$" + PropertyCause,
+	PropertyStack:      "$" + PropertyStack,
+	PropertyStatus:     "$" + PropertyStatus,
+	PropertyTimestamp:  "$" + PropertyTimestamp,
+	PropertyRetryAfter: "$" + PropertyRetryAfter,
+	PropertyRetryable:  "$" + PropertyRetryable,
+}
+
+func toSnake(s string) string {
+	if strings.IndexFunc(s, func(r rune) bool { return r >= 'A' && r <= 'Z' }) == -1 {
+		return s
+	}
+	var sb strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
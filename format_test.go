@@ -0,0 +1,99 @@
+package httperr
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseFormat_Name(t *testing.T) {
+	var nilFormat *ResponseFormat
+	require.Equal(t, "$error", nilFormat.Name(PropertyError))
+	require.Equal(t, "$retryAfter", (&ResponseFormat{}).Name("retryAfter"))
+	require.Equal(t, "retryAfter", (&ResponseFormat{Naming: NamingCamel}).Name("retryAfter"))
+	require.Equal(t, "retry_after", (&ResponseFormat{Naming: NamingSnake}).Name("retryAfter"))
+	rf := &ResponseFormat{
+		Naming:     NamingSnake,
+		Properties: map[string]string{PropertyError: "message", PropertyStack: ""},
+	}
+	require.Equal(t, "message", rf.Name(PropertyError))
+	require.Equal(t, "stack", rf.Name(PropertyStack))
+}
+
+func TestDefaultErrorWriter_WithResponseFormat(t *testing.T) {
+	defer func() {
+		DefaultResponseFormat = &ResponseFormat{}
+		timeNow = time.Now
+	}()
+	timeNow = func() time.Time {
+		return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	t.Run("legacy envelope", func(t *testing.T) {
+		DefaultResponseFormat = &ResponseFormat{
+			Naming:        NamingCamel,
+			Envelope:      "error",
+			Properties:    map[string]string{PropertyError: "message"},
+			IncludeStatus: true,
+		}
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewNotFoundError("").AddReason("foo"), w)
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"error": map[string]any{
+				"message": "Not Found",
+				"status":  float64(http.StatusNotFound),
+				"reasons": []any{"foo"},
+			},
+		}, body)
+	})
+	t.Run("timestamp and fields", func(t *testing.T) {
+		DefaultResponseFormat = &ResponseFormat{
+			Naming:           NamingSnake,
+			IncludeTimestamp: true,
+			Fields:           map[string]any{"api_version": "v1"},
+		}
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewBadRequestError("whoops"), w)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"error":       "whoops",
+			"timestamp":   "2025-01-02T03:04:05Z",
+			"api_version": "v1",
+		}, body)
+	})
+	t.Run("timestamp format", func(t *testing.T) {
+		DefaultResponseFormat = &ResponseFormat{
+			IncludeTimestamp: true,
+			TimestampFormat:  time.DateOnly,
+		}
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewBadRequestError("whoops"), w)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Equal(t, "2025-01-02", body["$timestamp"])
+	})
+	t.Run("nil format", func(t *testing.T) {
+		DefaultResponseFormat = nil
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewBadRequestError("whoops"), w)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{ptyError: "whoops"}, body)
+	})
+	t.Run("marshal ignores envelope", func(t *testing.T) {
+		DefaultResponseFormat = &ResponseFormat{
+			Naming:        NamingCamel,
+			Envelope:      "error",
+			IncludeStatus: true,
+		}
+		data, err := json.Marshal(NewConflictError("whoops"))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"error":"whoops","status":409}`, string(data))
+	})
+}
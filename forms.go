@@ -0,0 +1,281 @@
+package httperr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// MaxFormSize is the default maximum size of request body read by ParseForm and ParseMultipartForm (see WithMaxFormSize)
+var MaxFormSize int64 = 10 << 20
+
+// MaxFormMemory is the default maximum memory used by ParseMultipartForm for file parts (see WithMaxFormMemory)
+//
+// file parts that exceed this are stored in temporary files (see http.Request.ParseMultipartForm)
+var MaxFormMemory int64 = 32 << 20
+
+// reason codes used by ParseForm and ParseMultipartForm
+const (
+	CodeContentType = "contentType"
+	CodeCount       = "count"
+)
+
+const (
+	applicationFormUrlEncoded = "application/x-www-form-urlencoded"
+	multipartFormData         = "multipart/form-data"
+	sniffLen                  = 512
+)
+
+// FileConstraint is a constraint on the uploaded files for a multipart form field (see WithFileConstraint)
+type FileConstraint struct {
+	// MinFiles is the minimum number of files
+	MinFiles int
+	// MaxFiles is the maximum number of files (zero means no maximum)
+	MaxFiles int
+	// MaxSize is the maximum size of each file (zero means no maximum)
+	MaxSize int64
+	// ContentTypes is the allowed content types (e.g. "image/png" or "image/*") - determined by sniffing
+	// the file content (see http.DetectContentType), not the content type supplied by the client
+	//
+	// if empty, any content type is allowed
+	ContentTypes []string
+}
+
+// FormOption is an option for ParseForm and ParseMultipartForm
+type FormOption func(o *formOptions)
+
+type formOptions struct {
+	maxBodySize int64
+	maxMemory   int64
+	fields      []string
+	files       map[string]FileConstraint
+}
+
+// WithMaxFormSize sets the maximum request body size (default is MaxFormSize)
+func WithMaxFormSize(n int64) FormOption {
+	return func(o *formOptions) {
+		o.maxBodySize = n
+	}
+}
+
+// WithMaxFormMemory sets the maximum memory used for file parts (default is MaxFormMemory)
+func WithMaxFormMemory(n int64) FormOption {
+	return func(o *formOptions) {
+		o.maxMemory = n
+	}
+}
+
+// WithFileConstraint adds a constraint for the files uploaded in a multipart form field
+func WithFileConstraint(field string, c FileConstraint) FormOption {
+	return func(o *formOptions) {
+		if o.files == nil {
+			o.files = map[string]FileConstraint{}
+		}
+		if _, ok := o.files[field]; !ok {
+			o.fields = append(o.fields, field)
+		}
+		o.files[field] = c
+	}
+}
+
+// ParseForm parses the request form (see http.Request.ParseForm) with a limit on the request body size
+//
+// returns nil if parsing succeeded, otherwise:
+//   - 415 Unsupported Media Type if the request has a Content-Type other than application/x-www-form-urlencoded
+//   - 413 Request Entity Too Large if the request body exceeds the maximum size
+//   - 400 Bad Request if the form (or query) is malformed
+func ParseForm(r *http.Request, opts ...FormOption) HttpError {
+	if err := parseForm(r, newFormOptions(opts)); err != nil {
+		return err.newError(getStackInfo())
+	}
+	return nil
+}
+
+// ParseMultipartForm parses the request multipart form (see http.Request.ParseMultipartForm) with a limit on
+// the request body size and checks any file constraints (see WithFileConstraint)
+//
+// returns nil if parsing succeeded, otherwise:
+//   - 415 Unsupported Media Type if the request Content-Type is not multipart/form-data
+//   - 413 Request Entity Too Large if the request body exceeds the maximum size
+//   - 400 Bad Request if the form is malformed
+//
+// if file constraints fail, the error has a reason for each failure (the reason Field is the form field name
+// and Value is the file name) - the status is 413 if any file is too large, 415 if any file has a content type
+// that is not allowed, otherwise 400
+func ParseMultipartForm(r *http.Request, opts ...FormOption) HttpError {
+	if err := parseMultipartForm(r, newFormOptions(opts)); err != nil {
+		return err.newError(getStackInfo())
+	}
+	return nil
+}
+
+func newFormOptions(opts []FormOption) *formOptions {
+	o := &formOptions{
+		maxBodySize: MaxFormSize,
+		maxMemory:   MaxFormMemory,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func parseForm(r *http.Request, o *formOptions) *requestError {
+	if ct := r.Header.Get(hdrContentType); ct != "" {
+		if mt, _, _ := mime.ParseMediaType(ct); mt != applicationFormUrlEncoded {
+			return unsupportedFormType(ct, applicationFormUrlEncoded)
+		}
+	}
+	limitBody(r, o)
+	if err := r.ParseForm(); err != nil {
+		return translateFormError(err)
+	}
+	return nil
+}
+
+func parseMultipartForm(r *http.Request, o *formOptions) *requestError {
+	ct := r.Header.Get(hdrContentType)
+	if mt, _, _ := mime.ParseMediaType(ct); mt != multipartFormData {
+		return unsupportedFormType(ct, multipartFormData)
+	}
+	limitBody(r, o)
+	if err := r.ParseMultipartForm(o.maxMemory); err != nil {
+		return translateFormError(err)
+	}
+	return checkFiles(r.MultipartForm, o)
+}
+
+func limitBody(r *http.Request, o *formOptions) {
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = http.MaxBytesReader(nil, r.Body, o.maxBodySize)
+	}
+}
+
+func unsupportedFormType(ct string, expected string) *requestError {
+	return &requestError{
+		status:  http.StatusUnsupportedMediaType,
+		message: "unsupported content type",
+		reasons: []Reason{{Message: "content type must be " + expected, Value: ct}},
+	}
+}
+
+func translateFormError(err error) *requestError {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return &requestError{
+			status:  http.StatusRequestEntityTooLarge,
+			message: "request body too large",
+			reasons: []Reason{{Code: CodeTooLarge, Message: fmt.Sprintf("request body must not exceed %d bytes", mbe.Limit), Meta: map[string]any{"limit": mbe.Limit}}},
+		}
+	} else if errors.Is(err, multipart.ErrMessageTooLarge) {
+		return &requestError{
+			status:  http.StatusRequestEntityTooLarge,
+			message: "request body too large",
+			cause:   err,
+		}
+	}
+	return &requestError{
+		status:  http.StatusBadRequest,
+		message: "malformed form",
+		cause:   err,
+	}
+}
+
+func checkFiles(form *multipart.Form, o *formOptions) *requestError {
+	v := Validate().In(InForm)
+	status := http.StatusBadRequest
+	add := func(field string, st int, reason Reason) {
+		if filePriority(st) > filePriority(status) {
+			status = st
+		}
+		v.Add(field, reason)
+	}
+	for _, field := range o.fields {
+		c := o.files[field]
+		var files []*multipart.FileHeader
+		if form != nil {
+			files = form.File[field]
+		}
+		if len(files) < c.MinFiles || (c.MaxFiles > 0 && len(files) > c.MaxFiles) {
+			add(field, http.StatusBadRequest, fileCountReason(len(files), c))
+		}
+		for _, fh := range files {
+			if c.MaxSize > 0 && fh.Size > c.MaxSize {
+				add(field, http.StatusRequestEntityTooLarge, NewReason(fmt.Sprintf("file must not exceed %d bytes", c.MaxSize)).
+					WithCode(CodeTooLarge).WithValue(fh.Filename).WithMeta("limit", c.MaxSize))
+			}
+			if len(c.ContentTypes) > 0 {
+				if detected, err := sniffFile(fh); err != nil {
+					add(field, http.StatusBadRequest, NewReason("file could not be read").WithCode(CodeInvalid).WithValue(fh.Filename))
+				} else if !contentTypeAllowed(detected, c.ContentTypes) {
+					add(field, http.StatusUnsupportedMediaType, NewReason("file content type must be one of "+strings.Join(c.ContentTypes, ", ")).
+						WithCode(CodeContentType).WithValue(fh.Filename).WithMeta("contentType", detected).WithMeta("allowed", c.ContentTypes))
+				}
+			}
+		}
+	}
+	if v.Valid() {
+		return nil
+	}
+	return &requestError{validator: v.WithStatus(status)}
+}
+
+func filePriority(status int) int {
+	switch status {
+	case http.StatusRequestEntityTooLarge:
+		return 2
+	case http.StatusUnsupportedMediaType:
+		return 1
+	}
+	return 0
+}
+
+func fileCountReason(count int, c FileConstraint) Reason {
+	if count == 0 {
+		return NewReason("file is required").WithCode(CodeRequired)
+	}
+	var msg string
+	if count < c.MinFiles {
+		msg = fmt.Sprintf("at least %d files required", c.MinFiles)
+	} else {
+		msg = fmt.Sprintf("at most %d files allowed", c.MaxFiles)
+	}
+	reason := NewReason(msg).WithCode(CodeCount).WithValue(count).WithMeta("min", c.MinFiles)
+	if c.MaxFiles > 0 {
+		reason = reason.WithMeta("max", c.MaxFiles)
+	}
+	return reason
+}
+
+func sniffFile(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return mt, nil
+}
+
+func contentTypeAllowed(contentType string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == "*/*" || strings.EqualFold(a, contentType) {
+			return true
+		} else if prefix, ok := strings.CutSuffix(a, "/*"); ok && len(contentType) > len(prefix) &&
+			strings.EqualFold(contentType[:len(prefix)+1], prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package httperr
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testPng = []byte("\x89PNG\r\n\x1a\n0000")
+
+type testFile struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+func newMultipartRequest(t *testing.T, values map[string]string, files ...testFile) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range values {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	for _, f := range files {
+		fw, err := mw.CreateFormFile(f.field, f.filename)
+		require.NoError(t, err)
+		_, err = fw.Write(f.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	r := httptest.NewRequest(http.MethodPost, "/", &buf)
+	r.Header.Set(hdrContentType, mw.FormDataContentType())
+	return r
+}
+
+func TestParseForm(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/?a=1", strings.NewReader("b=2&c=3"))
+	r.Header.Set(hdrContentType, applicationFormUrlEncoded)
+	require.Nil(t, ParseForm(r))
+	require.Equal(t, "1", r.Form.Get("a"))
+	require.Equal(t, "2", r.PostForm.Get("b"))
+
+	r = httptest.NewRequest(http.MethodGet, "/?a=1", nil)
+	require.Nil(t, ParseForm(r))
+	require.Equal(t, "1", r.Form.Get("a"))
+}
+
+func TestParseForm_Errors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	r.Header.Set(hdrContentType, applicationJson)
+	err := ParseForm(r)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnsupportedMediaType, err.StatusCode())
+	require.Equal(t, []any{Reason{Message: "content type must be application/x-www-form-urlencoded", Value: applicationJson}}, err.Reasons())
+	require.Contains(t, err.StackInfo()[0].Function, "TestParseForm_Errors")
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a="+strings.Repeat("x", 100)))
+	r.Header.Set(hdrContentType, applicationFormUrlEncoded)
+	err = ParseForm(r, WithMaxFormSize(50))
+	require.Error(t, err)
+	require.Equal(t, http.StatusRequestEntityTooLarge, err.StatusCode())
+	require.Equal(t, []any{Reason{Code: CodeTooLarge, Message: "request body must not exceed 50 bytes", Meta: map[string]any{"limit": int64(50)}}}, err.Reasons())
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=%zz"))
+	r.Header.Set(hdrContentType, applicationFormUrlEncoded)
+	err = ParseForm(r)
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, err.StatusCode())
+	require.Equal(t, "malformed form", err.Error())
+	require.NotNil(t, err.Unwrap())
+}
+
+func TestParseMultipartForm(t *testing.T) {
+	r := newMultipartRequest(t, map[string]string{"name": "bob"},
+		testFile{"avatar", "me.png", testPng},
+		testFile{"docs", "a.txt", []byte("hello")},
+		testFile{"docs", "b.txt", []byte("world")})
+	err := ParseMultipartForm(r,
+		WithFileConstraint("avatar", FileConstraint{MinFiles: 1, MaxFiles: 1, MaxSize: 1024, ContentTypes: []string{"image/*"}}),
+		WithFileConstraint("docs", FileConstraint{MaxFiles: 2, ContentTypes: []string{"text/plain"}}),
+		WithFileConstraint("other", FileConstraint{}))
+	require.Nil(t, err)
+	require.Equal(t, "bob", r.FormValue("name"))
+	require.Len(t, r.MultipartForm.File["docs"], 2)
+}
+
+func TestParseMultipartForm_Errors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=1"))
+	r.Header.Set(hdrContentType, applicationFormUrlEncoded)
+	err := ParseMultipartForm(r)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnsupportedMediaType, err.StatusCode())
+	require.Equal(t, []any{Reason{Message: "content type must be multipart/form-data", Value: applicationFormUrlEncoded}}, err.Reasons())
+
+	r = newMultipartRequest(t, map[string]string{"name": strings.Repeat("x", 1000)})
+	err = ParseMultipartForm(r, WithMaxFormSize(100))
+	require.Error(t, err)
+	require.Equal(t, http.StatusRequestEntityTooLarge, err.StatusCode())
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("garbage"))
+	r.Header.Set(hdrContentType, "multipart/form-data; boundary=xyz")
+	err = ParseMultipartForm(r)
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, err.StatusCode())
+	require.Equal(t, "malformed form", err.Error())
+}
+
+func TestParseMultipartForm_FileConstraints(t *testing.T) {
+	r := newMultipartRequest(t, nil,
+		testFile{"avatar", "me.txt", []byte("not an image")},
+		testFile{"docs", "a.txt", []byte("a")},
+		testFile{"docs", "b.txt", []byte("b")})
+	err := ParseMultipartForm(r,
+		WithFileConstraint("avatar", FileConstraint{MinFiles: 1, ContentTypes: []string{"image/png", "image/jpeg"}}),
+		WithFileConstraint("docs", FileConstraint{MaxFiles: 1}),
+		WithFileConstraint("required", FileConstraint{MinFiles: 1}))
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnsupportedMediaType, err.StatusCode())
+	require.Contains(t, err.StackInfo()[0].Function, "TestParseMultipartForm_FileConstraints")
+	require.Equal(t, []any{
+		Reason{Code: CodeContentType, Message: "file content type must be one of image/png, image/jpeg", Field: "avatar", Value: "me.txt",
+			Meta: map[string]any{"in": InForm, "contentType": "text/plain", "allowed": []string{"image/png", "image/jpeg"}}},
+		Reason{Code: CodeCount, Message: "at most 1 files allowed", Field: "docs", Value: 2, Meta: map[string]any{"in": InForm, "min": 0, "max": 1}},
+		Reason{Code: CodeRequired, Message: "file is required", Field: "required", Meta: map[string]any{"in": InForm}},
+	}, err.Reasons())
+
+	r = newMultipartRequest(t, nil,
+		testFile{"avatar", "me.png", testPng},
+		testFile{"docs", "a.txt", []byte("a")})
+	err = ParseMultipartForm(r,
+		WithFileConstraint("avatar", FileConstraint{MaxSize: 4, ContentTypes: []string{"text/*"}}),
+		WithFileConstraint("docs", FileConstraint{MinFiles: 2, MaxFiles: 3}))
+	require.Error(t, err)
+	require.Equal(t, http.StatusRequestEntityTooLarge, err.StatusCode())
+	require.Equal(t, []any{
+		Reason{Code: CodeTooLarge, Message: "file must not exceed 4 bytes", Field: "avatar", Value: "me.png", Meta: map[string]any{"in": InForm, "limit": int64(4)}},
+		Reason{Code: CodeContentType, Message: "file content type must be one of text/*", Field: "avatar", Value: "me.png",
+			Meta: map[string]any{"in": InForm, "contentType": "image/png", "allowed": []string{"text/*"}}},
+		Reason{Code: CodeCount, Message: "at least 2 files required", Field: "docs", Value: 1, Meta: map[string]any{"in": InForm, "min": 2, "max": 3}},
+	}, err.Reasons())
+}
+
+func TestContentTypeAllowed(t *testing.T) {
+	require.True(t, contentTypeAllowed("image/png", []string{"*/*"}))
+	require.True(t, contentTypeAllowed("image/png", []string{"IMAGE/PNG"}))
+	require.True(t, contentTypeAllowed("image/png", []string{"text/plain", "image/*"}))
+	require.False(t, contentTypeAllowed("image/png", []string{"text/*"}))
+	require.False(t, contentTypeAllowed("imagex/png", []string{"image/*"}))
+}
@@ -0,0 +1,87 @@
+package httperr
+
+import "net/http"
+
+// HeaderPolicy determines which error headers are written to responses (by the DefaultErrorWriter or HttpError.Write)
+// and the default headers added to every error response
+type HeaderPolicy struct {
+	// Protected are the headers that errors cannot set (e.g. Content-Type, Content-Length)
+	Protected []string
+	// DefaultHeaders are the headers added to every error response (unless already set)
+	DefaultHeaders http.Header
+}
+
+// DefaultHeaderPolicy is the header policy applied when writing errors
+//
+// regardless of policy, error headers with invalid names or values (e.g. containing CR/LF) are never written
+//
+// if this is set to nil, no headers are protected and no default headers are added
+var DefaultHeaderPolicy = &HeaderPolicy{
+	Protected: []string{
+		hdrContentType,
+		hdrContentLength,
+		"Transfer-Encoding",
+		"Connection",
+		"Keep-Alive",
+		"Trailer",
+		"Upgrade",
+	},
+	DefaultHeaders: http.Header{
+		"X-Content-Type-Options": {"nosniff"},
+	},
+}
+
+// IsProtected determines whether the header is protected by the policy
+func (hp *HeaderPolicy) IsProtected(header string) bool {
+	if hp != nil {
+		header = http.CanonicalHeaderKey(header)
+		for _, p := range hp.Protected {
+			if http.CanonicalHeaderKey(p) == header {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (hp *HeaderPolicy) applyDefaults(hdrs http.Header, status int) {
+	if hp == nil {
+		return
+	}
+	for k, vs := range hp.DefaultHeaders {
+		if !headerAllowed(status, k) || hdrs.Get(k) != "" {
+			continue
+		}
+		for _, v := range vs {
+			hdrs.Add(k, v)
+		}
+	}
+}
+
+// ValidHeader determines whether the header name is a valid token and the value does not contain
+// control characters (e.g. CR/LF that could be used for header injection)
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-field-names and https://www.rfc-editor.org/rfc/rfc9110.html#name-field-values
+func ValidHeader(name string, value string) bool {
+	if !isToken(name) {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if b := value[i]; (b < 0x20 && b != '\t') || b == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func isTokenChar(b byte) bool {
+	switch {
+	case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+		return true
+	}
+	switch b {
+	case '!', '#', '$', '%', '&', '\'', '*', '+', '-', '.', '^', '_', '`', '|', '~':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package httperr
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidHeader(t *testing.T) {
+	require.True(t, ValidHeader("X-Foo", "bar"))
+	require.True(t, ValidHeader("X-Foo", "bar\tbaz"))
+	require.True(t, ValidHeader("X-Foo", ""))
+	require.False(t, ValidHeader("", "bar"))
+	require.False(t, ValidHeader("X Foo", "bar"))
+	require.False(t, ValidHeader("X-Foo:", "bar"))
+	require.False(t, ValidHeader("X-Foo", "bar\r\nSet-Cookie: x=y"))
+	require.False(t, ValidHeader("X-Foo", "bar\x00"))
+	require.False(t, ValidHeader("X-Foo", "bar\x7f"))
+}
+
+func TestHeaderPolicy_IsProtected(t *testing.T) {
+	require.True(t, DefaultHeaderPolicy.IsProtected("content-type"))
+	require.True(t, DefaultHeaderPolicy.IsProtected("Transfer-Encoding"))
+	require.False(t, DefaultHeaderPolicy.IsProtected("X-Foo"))
+	var nilPolicy *HeaderPolicy
+	require.False(t, nilPolicy.IsProtected("Content-Type"))
+}
+
+func TestDefaultErrorWriter_HeaderPolicy(t *testing.T) {
+	t.Run("protected headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		e := NewBadRequestError("whoops").AddHeaders(map[string]string{
+			"Content-Type":      "text/html",
+			"Content-Length":    "1",
+			"Transfer-Encoding": "chunked",
+		})
+		DefaultErrorWriter.WriteError(e, w)
+		require.Equal(t, applicationJson, w.Header().Get("Content-Type"))
+		require.NotEqual(t, "1", w.Header().Get("Content-Length"))
+		require.Empty(t, w.Header().Get("Transfer-Encoding"))
+	})
+	t.Run("invalid headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		e := NewBadRequestError("whoops").
+			AddHeader("X-Foo", "bar\r\nSet-Cookie: x=y").
+			AddHeader("X Bad", "bar").(HeaderSetter).
+			AppendHeader("X-Multi", "ok").
+			AppendHeader("X-Multi", "bad\n")
+		DefaultErrorWriter.WriteError(e, w)
+		require.Empty(t, w.Header().Get("X-Foo"))
+		require.Empty(t, w.Header().Get("Set-Cookie"))
+		require.Empty(t, w.Header().Values("X Bad"))
+		require.Equal(t, []string{"ok"}, w.Header().Values("X-Multi"))
+	})
+	t.Run("default headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewBadRequestError("whoops"), w)
+		require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		require.Empty(t, w.Header().Get("Cache-Control"))
+	})
+	t.Run("no-store for 5xx", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewInternalServerError("whoops", nil), w)
+		require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+	t.Run("5xx with own Cache-Control", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewServiceUnavailableError("whoops", nil).AddHeader("Cache-Control", "max-age=60"), w)
+		require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		require.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+	})
+	t.Run("plain error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(http.ErrAbortHandler, w)
+		require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+	t.Run("not modified", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewNotModifiedError(""), w)
+		require.Empty(t, w.Header().Get("X-Content-Type-Options"))
+	})
+	t.Run("no policy", func(t *testing.T) {
+		defer func(hp *HeaderPolicy) {
+			DefaultHeaderPolicy = hp
+		}(DefaultHeaderPolicy)
+		defer func(cp *CachePolicy) {
+			DefaultCachePolicy = cp
+		}(DefaultCachePolicy)
+		DefaultHeaderPolicy = nil
+		DefaultCachePolicy = nil
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewInternalServerError("whoops", nil).AddHeader("Transfer-Encoding", "chunked"), w)
+		require.Empty(t, w.Header().Get("X-Content-Type-Options"))
+		require.Empty(t, w.Header().Get("Cache-Control"))
+		require.Equal(t, "chunked", w.Header().Get("Transfer-Encoding"))
+	})
+	t.Run("no default writer", func(t *testing.T) {
+		DefaultErrorWriter = nil
+		defer func() {
+			DefaultErrorWriter = &errorWriter{}
+		}()
+		w := httptest.NewRecorder()
+		NewServiceUnavailableError("", nil).AddHeader("Content-Type", "text/html").Write(w)
+		require.Empty(t, w.Header().Get("Content-Type"))
+		require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+}
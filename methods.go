@@ -0,0 +1,107 @@
+package httperr
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const hdrAllow = "Allow"
+
+// AllowSetter is a HttpError with allowed methods (e.g. for 405 Method Not Allowed)
+//
+// implemented by all errors created by this package (but not part of HttpError) - for example...
+//
+//	if as, ok := err.(httperr.AllowSetter); ok {
+//		as.WithAllow(http.MethodGet, http.MethodPost)
+//	}
+type AllowSetter interface {
+	HttpError
+	AllowError
+	// WithAllow sets the allowed methods for the error
+	//
+	// the methods are added as the Allow header - method names are case-sensitive, so are written as supplied
+	WithAllow(methods ...string) AllowSetter
+}
+
+var _ AllowSetter = (*httpError)(nil)
+
+// MethodHandlers is a router agnostic http.Handler that dispatches requests to handlers by request method
+//
+// requests for methods that have no handler are responded to with a 405 Method Not Allowed error that
+// has the required Allow header, HEAD requests are dispatched to the GET handler (if there is no HEAD handler)
+// and OPTIONS requests are responded to with 204 No Content and an Allow header (if there is no OPTIONS handler)
+//
+// example:
+//
+//	mux.Handle("/items/{id}", httperr.MethodHandlers{
+//		http.MethodGet:    http.HandlerFunc(getItem),
+//		http.MethodDelete: http.HandlerFunc(deleteItem),
+//	})
+type MethodHandlers map[string]http.Handler
+
+var _ http.Handler = MethodHandlers(nil)
+
+func (mh MethodHandlers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h := mh[r.Method]; h != nil {
+		h.ServeHTTP(w, r)
+		return
+	}
+	if h := mh[http.MethodGet]; h != nil && r.Method == http.MethodHead {
+		h.ServeHTTP(w, r)
+		return
+	}
+	allowed := mh.Allowed()
+	if r.Method == http.MethodOptions {
+		w.Header().Set(hdrAllow, strings.Join(allowed, ", "))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	NewMethodNotAllowedAllowError("", allowed...).(RequestWriter).WriteRequest(w, r)
+}
+
+// Allowed returns the allowed methods - including HEAD (if there is a GET handler) and OPTIONS
+func (mh MethodHandlers) Allowed() []string {
+	result := make([]string, 0, len(mh)+2)
+	for m, h := range mh {
+		if h != nil {
+			result = append(result, m)
+		}
+	}
+	if mh[http.MethodGet] != nil && !slices.Contains(result, http.MethodHead) {
+		result = append(result, http.MethodHead)
+	}
+	if !slices.Contains(result, http.MethodOptions) {
+		result = append(result, http.MethodOptions)
+	}
+	sortMethods(result)
+	return result
+}
+
+var methodOrder = map[string]int{
+	http.MethodGet:     1,
+	http.MethodHead:    2,
+	http.MethodPost:    3,
+	http.MethodPut:     4,
+	http.MethodPatch:   5,
+	http.MethodDelete:  6,
+	http.MethodConnect: 7,
+	http.MethodOptions: 8,
+	http.MethodTrace:   9,
+}
+
+// sortMethods sorts methods into conventional order (with unknown methods last, alphabetically)
+func sortMethods(methods []string) {
+	slices.SortFunc(methods, func(a, b string) int {
+		oa, ob := methodOrder[a], methodOrder[b]
+		switch {
+		case oa != 0 && ob != 0:
+			return oa - ob
+		case oa != 0:
+			return -1
+		case ob != 0:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+}
@@ -0,0 +1,80 @@
+package httperr
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodHandlers(t *testing.T) {
+	handler := func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+	}
+	mh := MethodHandlers{
+		http.MethodGet:    handler(http.StatusOK),
+		http.MethodDelete: handler(http.StatusNoContent),
+		"PURGE":           handler(http.StatusAccepted),
+		http.MethodPut:    nil,
+	}
+	t.Run("Allowed", func(t *testing.T) {
+		require.Equal(t, []string{"GET", "HEAD", "DELETE", "OPTIONS", "PURGE"}, mh.Allowed())
+		require.Equal(t, []string{"OPTIONS"}, MethodHandlers{}.Allowed())
+	})
+	t.Run("dispatch", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mh.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/", nil))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = httptest.NewRecorder()
+		mh.ServeHTTP(w, httptest.NewRequest("PURGE", "/", nil))
+		require.Equal(t, http.StatusAccepted, w.Code)
+	})
+	t.Run("HEAD", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mh.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+	t.Run("OPTIONS", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mh.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/", nil))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "GET, HEAD, DELETE, OPTIONS, PURGE", w.Header().Get("Allow"))
+		require.Empty(t, w.Body.Bytes())
+	})
+	t.Run("OPTIONS (with handler)", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		MethodHandlers{http.MethodOptions: handler(http.StatusOK)}.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+	t.Run("not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mh.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "GET, HEAD, DELETE, OPTIONS, PURGE", w.Header().Get("Allow"))
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Equal(t, "Method Not Allowed", body[ptyError])
+	})
+}
+
+func TestError_WithAllow(t *testing.T) {
+	e := NewMethodNotAllowedError("").(AllowSetter).WithAllow("GET", " HEAD", "GET", "", "mkCalendar")
+	require.Equal(t, []string{"GET", "HEAD", "mkCalendar"}, e.Allow())
+	require.Equal(t, "GET, HEAD, mkCalendar", e.(HeaderSetter).Header().Get("Allow"))
+	e = NewMethodNotAllowedError("").(AllowSetter).WithAllow()
+	require.Empty(t, e.Allow())
+	require.Equal(t, []string{""}, e.(HeaderSetter).Header().Values("Allow"))
+}
+
+func TestError_Allow_FromHeader(t *testing.T) {
+	e := NewMethodNotAllowedError("").AddHeader("Allow", "GET, POST,GET")
+	require.Equal(t, []string{"GET", "POST"}, e.(AllowError).Allow())
+
+	w := httptest.NewRecorder()
+	w.Header().Set("Allow", "GET, POST")
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	e = FromResponse(w.Result())
+	require.Equal(t, []string{"GET", "POST"}, e.(AllowError).Allow())
+}
@@ -0,0 +1,224 @@
+package httperr
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	hdrAccept         = "Accept"
+	hdrAcceptLanguage = "Accept-Language"
+	hdrAcceptEncoding = "Accept-Encoding"
+	encodingIdentity  = "identity"
+)
+
+// CodeNotAcceptable is the reason code used by Negotiate, NegotiateLanguage and NegotiateEncoding when none of the offers are acceptable
+const CodeNotAcceptable = "notAcceptable"
+
+// Negotiate returns the best of the offered media types (e.g. "application/json") acceptable according
+// to the request Accept header - using q-values, wildcards (e.g. "text/*" or "*/*") and media type parameters
+//
+// offers should be in order of server preference - where the client accepts several offers equally, the first is returned.
+// If the request has no Accept header, the first offer is returned
+//
+// if none of the offers are acceptable, returns a 406 Not Acceptable error with a reason listing the available
+// media types (in the reason Meta "available")
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-accept
+func Negotiate(r *http.Request, offers ...string) (string, HttpError) {
+	if offer, ok := negotiate(r.Header.Values(hdrAccept), offers, matchMediaType, false); ok {
+		return offer, nil
+	}
+	return "", notAcceptable(hdrAccept, offers, getStackInfo())
+}
+
+// NegotiateLanguage returns the best of the offered language tags (e.g. "en-GB") acceptable according to the
+// request Accept-Language header - language ranges match by prefix (e.g. "en" matches "en-GB")
+//
+// offers should be in order of server preference.  If the request has no Accept-Language header, the first offer is returned
+//
+// if none of the offers are acceptable, returns a 406 Not Acceptable error with a reason listing the available languages
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-accept-language
+func NegotiateLanguage(r *http.Request, offers ...string) (string, HttpError) {
+	if offer, ok := negotiate(r.Header.Values(hdrAcceptLanguage), offers, matchLanguage, false); ok {
+		return offer, nil
+	}
+	return "", notAcceptable(hdrAcceptLanguage, offers, getStackInfo())
+}
+
+// NegotiateEncoding returns the best of the offered content codings (e.g. "gzip" or "identity") acceptable according to the
+// request Accept-Encoding header - "identity" is acceptable unless explicitly excluded (by "identity;q=0" or "*;q=0")
+//
+// offers should be in order of server preference.  If the request has no Accept-Encoding header, the first offer is returned
+//
+// if none of the offers are acceptable, returns a 406 Not Acceptable error with a reason listing the available encodings
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-accept-encoding
+func NegotiateEncoding(r *http.Request, offers ...string) (string, HttpError) {
+	if offer, ok := negotiate(r.Header.Values(hdrAcceptEncoding), offers, matchEncoding, true); ok {
+		return offer, nil
+	}
+	return "", notAcceptable(hdrAcceptEncoding, offers, getStackInfo())
+}
+
+func notAcceptable(header string, offers []string, si StackInfo) HttpError {
+	available := append([]string{}, offers...)
+	return newError(http.StatusNotAcceptable, "", nil, si).
+		SetHeader(hdrVary, header).
+		AddReasons(FieldReason(header, "no acceptable representation - available: "+strings.Join(offers, ", ")).
+			WithCode(CodeNotAcceptable).WithMeta("in", InHeader).WithMeta("available", available))
+}
+
+// acceptSpec is a single element of an Accept (or Accept-Language, Accept-Encoding) header
+type acceptSpec struct {
+	value  string
+	params [][2]string
+	q      float64
+}
+
+// matcher returns the specificity of the spec match for the offer - or -1 if the spec does not match
+type matcher func(spec acceptSpec, offer string) int
+
+// negotiate returns the offer with the highest q-value (of its most specific matching spec)
+//
+// if identity is true, the "identity" coding is acceptable unless explicitly excluded
+func negotiate(headers []string, offers []string, match matcher, identity bool) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	} else if len(headers) == 0 {
+		return offers[0], true
+	}
+	specs := parseAccept(headers)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, spec := range specs {
+			if s := match(spec, offer); s > specificity {
+				q, specificity = spec.q, s
+			}
+		}
+		if specificity < 0 && identity && strings.EqualFold(offer, encodingIdentity) {
+			q = 1
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+func parseAccept(headers []string) []acceptSpec {
+	result := make([]acceptSpec, 0)
+	for _, hdr := range headers {
+		for _, element := range splitQuoted(hdr, ',') {
+			parts := splitQuoted(element, ';')
+			spec := acceptSpec{value: strings.ToLower(strings.TrimSpace(parts[0])), q: 1}
+			if spec.value == "" {
+				continue
+			}
+			valid := true
+			for _, p := range parts[1:] {
+				name, value, _ := strings.Cut(p, "=")
+				name = strings.ToLower(strings.TrimSpace(name))
+				value = strings.Trim(strings.TrimSpace(value), `"`)
+				if name == "q" {
+					q, err := strconv.ParseFloat(value, 64)
+					if err != nil || q < 0 || q > 1 {
+						valid = false
+						break
+					}
+					spec.q = q
+					break
+				} else if name != "" {
+					spec.params = append(spec.params, [2]string{name, value})
+				}
+			}
+			if valid {
+				result = append(result, spec)
+			}
+		}
+	}
+	return result
+}
+
+// splitQuoted splits s by the separator - ignoring separators in quoted strings
+func splitQuoted(s string, sep byte) []string {
+	result := make([]string, 0)
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			result = append(result, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(result, s[start:])
+}
+
+func matchMediaType(spec acceptSpec, offer string) int {
+	offerType, offerParams, _ := strings.Cut(offer, ";")
+	offerType = strings.ToLower(strings.TrimSpace(offerType))
+	specMain, specSub, _ := strings.Cut(spec.value, "/")
+	offerMain, offerSub, _ := strings.Cut(offerType, "/")
+	specificity := 0
+	switch {
+	case specMain == "*" && specSub == "*":
+		specificity = 1
+	case specMain == offerMain && specSub == "*":
+		specificity = 2
+	case specMain == offerMain && specSub == offerSub:
+		specificity = 3
+	default:
+		return -1
+	}
+	if len(spec.params) > 0 {
+		params := parseAccept([]string{"x;" + offerParams})
+		for _, sp := range spec.params {
+			if !hasParam(params, sp) {
+				return -1
+			}
+		}
+		specificity += len(spec.params)
+	}
+	return specificity
+}
+
+func hasParam(specs []acceptSpec, param [2]string) bool {
+	if len(specs) == 0 {
+		return false
+	}
+	for _, p := range specs[0].params {
+		if p[0] == param[0] && strings.EqualFold(p[1], param[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchLanguage(spec acceptSpec, offer string) int {
+	switch {
+	case spec.value == "*":
+		return 0
+	case strings.EqualFold(spec.value, offer):
+		return len(spec.value) + 1
+	case len(offer) > len(spec.value) && offer[len(spec.value)] == '-' && strings.EqualFold(offer[:len(spec.value)], spec.value):
+		return len(spec.value)
+	}
+	return -1
+}
+
+func matchEncoding(spec acceptSpec, offer string) int {
+	switch {
+	case spec.value == "*":
+		return 0
+	case strings.EqualFold(spec.value, offer):
+		return 1
+	}
+	return -1
+}
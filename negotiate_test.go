@@ -0,0 +1,141 @@
+package httperr
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		accept string
+		offers []string
+		expect string
+	}{
+		{accept: "", offers: []string{"application/json", "text/html"}, expect: "application/json"},
+		{accept: "text/html", offers: []string{"application/json", "text/html"}, expect: "text/html"},
+		{accept: "TEXT/HTML", offers: []string{"application/json", "text/html"}, expect: "text/html"},
+		{accept: "*/*", offers: []string{"application/json", "text/html"}, expect: "application/json"},
+		{accept: "text/*", offers: []string{"application/json", "text/html"}, expect: "text/html"},
+		{accept: "text/html;q=0.5, application/json;q=0.8", offers: []string{"text/html", "application/json"}, expect: "application/json"},
+		{accept: "text/*;q=0.1, */*;q=0.5", offers: []string{"text/html", "application/json"}, expect: "application/json"},
+		{accept: "text/html;q=0, */*", offers: []string{"text/html", "application/json"}, expect: "application/json"},
+		{accept: "text/html;level=1, text/html;q=0.2", offers: []string{"text/html", "text/html;level=1"}, expect: "text/html;level=1"},
+		{accept: `text/plain;charset="UTF-8"`, offers: []string{"text/plain", "text/plain; charset=utf-8"}, expect: "text/plain; charset=utf-8"},
+		{accept: "application/json;q=x, text/html", offers: []string{"application/json", "text/html"}, expect: "text/html"},
+		{accept: "application/json;q=0.9;ext=1", offers: []string{"application/json"}, expect: "application/json"},
+		{accept: `text/plain;p="a,b", application/json;q=0.1`, offers: []string{"application/json", `text/plain;p="a,b"`}, expect: `text/plain;p="a,b"`},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.accept != "" {
+			r.Header.Set(hdrAccept, tc.accept)
+		}
+		offer, err := Negotiate(r, tc.offers...)
+		require.Nil(t, err, i)
+		require.Equal(t, tc.expect, offer, i)
+	}
+}
+
+func TestNegotiate_NotAcceptable(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(hdrAccept, "text/html, application/xml;q=0.5, application/json;q=0")
+	offer, err := Negotiate(r, "application/json", "text/csv")
+	require.Equal(t, "", offer)
+	require.Error(t, err)
+	require.Equal(t, http.StatusNotAcceptable, err.StatusCode())
+	require.Equal(t, hdrAccept, err.(HeaderSetter).Header().Get(hdrVary))
+	require.Contains(t, err.StackInfo()[0].Function, "TestNegotiate_NotAcceptable")
+	require.Equal(t, []any{Reason{
+		Code:    CodeNotAcceptable,
+		Message: "no acceptable representation - available: application/json, text/csv",
+		Field:   hdrAccept,
+		Meta:    map[string]any{"in": InHeader, "available": []string{"application/json", "text/csv"}},
+	}}, err.Reasons())
+
+	w := httptest.NewRecorder()
+	err.(RequestWriter).WriteRequest(w, r)
+	require.Equal(t, http.StatusNotAcceptable, w.Code)
+	require.Contains(t, w.Body.String(), `"available":["application/json","text/csv"]`)
+
+	_, err = Negotiate(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Error(t, err)
+	require.Equal(t, http.StatusNotAcceptable, err.StatusCode())
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	testCases := []struct {
+		accept string
+		offers []string
+		expect string
+	}{
+		{accept: "", offers: []string{"en", "fr"}, expect: "en"},
+		{accept: "fr", offers: []string{"en", "fr"}, expect: "fr"},
+		{accept: "en", offers: []string{"fr", "en-GB"}, expect: "en-GB"},
+		{accept: "en-GB, en;q=0.8, *;q=0.1", offers: []string{"fr", "en-US", "en-GB"}, expect: "en-GB"},
+		{accept: "en-gb;q=0.5, fr;q=0.9", offers: []string{"en-GB", "fr-CA"}, expect: "fr-CA"},
+		{accept: "*", offers: []string{"de", "fr"}, expect: "de"},
+		{accept: "en;q=0.5, en-US;q=0", offers: []string{"en-US", "en-GB"}, expect: "en-GB"},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.accept != "" {
+			r.Header.Set(hdrAcceptLanguage, tc.accept)
+		}
+		offer, err := NegotiateLanguage(r, tc.offers...)
+		require.Nil(t, err, i)
+		require.Equal(t, tc.expect, offer, i)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(hdrAcceptLanguage, "de, en-GB")
+	_, err := NegotiateLanguage(r, "en", "fr")
+	require.Error(t, err)
+	require.Equal(t, http.StatusNotAcceptable, err.StatusCode())
+	require.Equal(t, hdrAcceptLanguage, err.Reasons()[0].(Reason).Field)
+	require.Contains(t, err.StackInfo()[0].Function, "TestNegotiateLanguage")
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		accept *string
+		offers []string
+		expect string
+		err    bool
+	}{
+		{offers: []string{"gzip", "identity"}, expect: "gzip"},
+		{accept: ptr(""), offers: []string{"gzip", "identity"}, expect: "identity"},
+		{accept: ptr("gzip"), offers: []string{"br", "gzip", "identity"}, expect: "gzip"},
+		{accept: ptr("br;q=0.5, gzip"), offers: []string{"br", "gzip"}, expect: "gzip"},
+		{accept: ptr("deflate"), offers: []string{"gzip", "identity"}, expect: "identity"},
+		{accept: ptr("GZIP"), offers: []string{"gzip"}, expect: "gzip"},
+		{accept: ptr("*"), offers: []string{"br", "gzip"}, expect: "br"},
+		{accept: ptr("deflate, identity;q=0"), offers: []string{"gzip", "identity"}, err: true},
+		{accept: ptr("deflate, *;q=0"), offers: []string{"gzip", "identity"}, err: true},
+		{accept: ptr("deflate"), offers: []string{"gzip"}, err: true},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.accept != nil {
+			r.Header[hdrAcceptEncoding] = []string{*tc.accept}
+		}
+		offer, err := NegotiateEncoding(r, tc.offers...)
+		if tc.err {
+			require.Error(t, err, i)
+			require.Equal(t, http.StatusNotAcceptable, err.StatusCode(), i)
+			require.Equal(t, hdrAcceptEncoding, err.(HeaderSetter).Header().Get(hdrVary), i)
+		} else {
+			require.Nil(t, err, i)
+			require.Equal(t, tc.expect, offer, i)
+		}
+	}
+}
+
+func TestSplitQuoted(t *testing.T) {
+	require.Equal(t, []string{"a", ` b;p="x,\"y"`, " c"}, splitQuoted(`a, b;p="x,\"y", c`, ','))
+	require.Equal(t, []string{""}, splitQuoted("", ','))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
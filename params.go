@@ -0,0 +1,291 @@
+package httperr
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parameter locations (set as the "in" meta of reasons added by Params, ParseMultipartForm and Validator.In)
+const (
+	InQuery  = "query"
+	InPath   = "path"
+	InHeader = "header"
+	InForm   = "form"
+)
+
+// Params parses typed request parameters (query, path and header) and accumulates parse failures (as Reason)
+//
+// example:
+//
+//	p := httperr.NewParams(r)
+//	limit := p.QueryInt("limit", 20, 1, 100)
+//	id := p.PathUUID("id")
+//	since := p.HeaderTime("If-Modified-Since")
+//	if err := p.Err(); err != nil {
+//		err.Write(w)
+//		return
+//	}
+//
+// when a parameter is invalid, the getter returns the default (or zero) value and a reason is added -
+// the reason Field is the parameter name and the Meta "in" identifies the location (InQuery, InPath or InHeader)
+//
+// failures are accumulated in a Validator (with status 400 Bad Request - see Validator.In) so the reasons
+// are the same as those of an equivalent Validator check
+type Params struct {
+	r         *http.Request
+	query     map[string][]string
+	validator *Validator
+}
+
+// NewParams creates a new Params for the request
+func NewParams(r *http.Request) *Params {
+	return &Params{r: r, validator: Validate().WithStatus(http.StatusBadRequest)}
+}
+
+// Query returns the named query parameter (or the default if absent)
+func (p *Params) Query(name string, def string) string {
+	if s, ok := p.queryValue(name); ok {
+		return s
+	}
+	return def
+}
+
+// QueryRequired returns the named query parameter - adding a reason if absent
+func (p *Params) QueryRequired(name string) string {
+	s, _ := p.queryValue(name)
+	p.validator.In(InQuery).Required(name, s)
+	return s
+}
+
+// QueryList returns the named query parameter values - repeated parameters and comma separated values are combined
+func (p *Params) QueryList(name string) []string {
+	var result []string
+	for _, v := range p.queryValues()[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+// QueryInt returns the named query parameter as an int between min and max (inclusive)
+//
+// returns the default if the parameter is absent or invalid
+func (p *Params) QueryInt(name string, def int, min int, max int) int {
+	if s, ok := p.queryValue(name); ok {
+		return p.parseInt(InQuery, name, s, def, min, max)
+	}
+	return def
+}
+
+// QueryFloat returns the named query parameter as a (finite) float64 between min and max (inclusive)
+//
+// returns the default if the parameter is absent or invalid (including "NaN" and "Inf")
+func (p *Params) QueryFloat(name string, def float64, min float64, max float64) float64 {
+	if s, ok := p.queryValue(name); ok {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			p.add(InQuery, name, s, NewReason("must be a number").WithCode(CodeInvalid))
+			return def
+		} else if f < min || f > max {
+			p.validator.In(InQuery).Range(name, f, min, max)
+			return def
+		}
+		return f
+	}
+	return def
+}
+
+// QueryBool returns the named query parameter as a bool
+//
+// returns the default if the parameter is absent or invalid
+func (p *Params) QueryBool(name string, def bool) bool {
+	if s, ok := p.queryValue(name); ok {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			p.add(InQuery, name, s, NewReason("must be a boolean").WithCode(CodeInvalid))
+			return def
+		}
+		return b
+	}
+	return def
+}
+
+// QueryTime returns the named query parameter as a time (parsed as RFC3339)
+//
+// returns the default if the parameter is absent or invalid
+func (p *Params) QueryTime(name string, def time.Time) time.Time {
+	if s, ok := p.queryValue(name); ok {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			p.add(InQuery, name, s, NewReason("must be an RFC3339 date-time").WithCode(CodeInvalid))
+			return def
+		}
+		return t
+	}
+	return def
+}
+
+// QueryOneOf returns the named query parameter - adding a reason if it is not one of the allowed values
+//
+// returns the default if the parameter is absent or invalid
+func (p *Params) QueryOneOf(name string, def string, allowed ...string) string {
+	if s, ok := p.queryValue(name); ok {
+		if slices.Contains(allowed, s) {
+			return s
+		}
+		values := make([]any, len(allowed))
+		for i, a := range allowed {
+			values[i] = a
+		}
+		p.validator.In(InQuery).OneOf(name, s, values...)
+	}
+	return def
+}
+
+// Path returns the named path parameter (see http.Request.PathValue) - adding a reason if absent
+func (p *Params) Path(name string) string {
+	s := p.r.PathValue(name)
+	p.validator.In(InPath).Required(name, s)
+	return s
+}
+
+// PathInt returns the named path parameter as an int between min and max (inclusive)
+//
+// returns zero if the parameter is absent or invalid
+func (p *Params) PathInt(name string, min int, max int) int {
+	if s := p.Path(name); s != "" {
+		return p.parseInt(InPath, name, s, 0, min, max)
+	}
+	return 0
+}
+
+// PathUUID returns the named path parameter (lower-cased) - adding a reason if it is not a valid UUID
+//
+// returns an empty string if the parameter is absent or invalid
+func (p *Params) PathUUID(name string) string {
+	if s := p.Path(name); s != "" {
+		if !isUUID(s) {
+			p.add(InPath, name, s, NewReason("must be a UUID").WithCode(CodeInvalid))
+			return ""
+		}
+		return strings.ToLower(s)
+	}
+	return ""
+}
+
+// Header returns the named request header (or the default if absent)
+func (p *Params) Header(name string, def string) string {
+	if s := p.r.Header.Get(name); s != "" {
+		return s
+	}
+	return def
+}
+
+// HeaderInt returns the named request header as an int between min and max (inclusive)
+//
+// returns the default if the header is absent or invalid
+func (p *Params) HeaderInt(name string, def int, min int, max int) int {
+	if s := p.r.Header.Get(name); s != "" {
+		return p.parseInt(InHeader, name, s, def, min, max)
+	}
+	return def
+}
+
+// HeaderTime returns the named request header as a time (parsed as a HTTP date - see http.ParseTime)
+//
+// returns a zero time if the header is absent or invalid
+func (p *Params) HeaderTime(name string) time.Time {
+	if s := p.r.Header.Get(name); s != "" {
+		t, err := http.ParseTime(s)
+		if err != nil {
+			p.add(InHeader, name, s, NewReason("must be a HTTP date").WithCode(CodeInvalid))
+			return time.Time{}
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Add adds a failure reason for a parameter (e.g. for custom parameter checks)
+func (p *Params) Add(in string, name string, reason Reason) *Params {
+	p.validator.In(in).Add(name, reason)
+	return p
+}
+
+// Valid returns whether there are no parameter failures
+func (p *Params) Valid() bool {
+	return p.validator.Valid()
+}
+
+// Reasons returns the parameter failures
+func (p *Params) Reasons() []Reason {
+	return p.validator.Reasons()
+}
+
+// Err returns a 400 Bad Request HttpError with all parameter failures as reasons - or nil if there are no failures
+func (p *Params) Err() HttpError {
+	if p.Valid() {
+		return nil
+	}
+	return p.validator.newError(getStackInfo())
+}
+
+func (p *Params) add(in string, name string, value string, reason Reason) {
+	if value != "" {
+		reason = reason.WithValue(value)
+	}
+	p.Add(in, name, reason)
+}
+
+func (p *Params) queryValues() map[string][]string {
+	if p.query == nil {
+		p.query = p.r.URL.Query()
+	}
+	return p.query
+}
+
+func (p *Params) queryValue(name string) (string, bool) {
+	if vs := p.queryValues()[name]; len(vs) > 0 && vs[0] != "" {
+		return vs[0], true
+	}
+	return "", false
+}
+
+func (p *Params) parseInt(in string, name string, s string, def int, min int, max int) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		p.add(in, name, s, NewReason("must be an integer").WithCode(CodeInvalid))
+		return def
+	} else if i < min || i > max {
+		p.validator.In(in).Range(name, i, float64(min), float64(max))
+		return def
+	}
+	return i
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')) {
+				return false
+			}
+		}
+	}
+	return true
+}
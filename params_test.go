@@ -0,0 +1,119 @@
+package httperr
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newParamsRequest(target string, pathValues map[string]string, headers map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range pathValues {
+		r.SetPathValue(k, v)
+	}
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+func TestParams_Valid(t *testing.T) {
+	r := newParamsRequest("/things?limit=50&q=foo&ratio=0.5&on=true&at=2024-01-02T03:04:05Z&sort=name&tags=a,b&tags=c&empty=",
+		map[string]string{"id": "0E8A1C5E-6F3B-4A1D-9C2E-7B8D9E0F1A2B", "n": "3"},
+		map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT", "X-Count": "7"})
+	p := NewParams(r)
+	require.Equal(t, 50, p.QueryInt("limit", 20, 1, 100))
+	require.Equal(t, 20, p.QueryInt("offset", 20, 1, 100))
+	require.Equal(t, 20, p.QueryInt("empty", 20, 1, 100))
+	require.Equal(t, "foo", p.Query("q", "bar"))
+	require.Equal(t, "bar", p.Query("missing", "bar"))
+	require.Equal(t, "foo", p.QueryRequired("q"))
+	require.Equal(t, 0.5, p.QueryFloat("ratio", 1, 0, 1))
+	require.Equal(t, 1.0, p.QueryFloat("missing", 1, 0, 1))
+	require.True(t, p.QueryBool("on", false))
+	require.True(t, p.QueryBool("missing", true))
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), p.QueryTime("at", time.Time{}))
+	require.True(t, p.QueryTime("missing", time.Time{}).IsZero())
+	require.Equal(t, "name", p.QueryOneOf("sort", "id", "id", "name"))
+	require.Equal(t, "id", p.QueryOneOf("missing", "id", "id", "name"))
+	require.Equal(t, []string{"a", "b", "c"}, p.QueryList("tags"))
+	require.Nil(t, p.QueryList("missing"))
+	require.Equal(t, "0e8a1c5e-6f3b-4a1d-9c2e-7b8d9e0f1a2b", p.PathUUID("id"))
+	require.Equal(t, 3, p.PathInt("n", 1, 10))
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), p.HeaderTime("If-Modified-Since"))
+	require.True(t, p.HeaderTime("If-Unmodified-Since").IsZero())
+	require.Equal(t, 7, p.HeaderInt("X-Count", 0, 0, 10))
+	require.Equal(t, 1, p.HeaderInt("X-Missing", 1, 0, 10))
+	require.Equal(t, "7", p.Header("X-Count", ""))
+	require.Equal(t, "def", p.Header("X-Missing", "def"))
+	require.True(t, p.Valid())
+	require.Empty(t, p.Reasons())
+	require.Nil(t, p.Err())
+}
+
+func TestParams_Invalid(t *testing.T) {
+	r := newParamsRequest("/things?limit=500&offset=x&ratio=y&ratio2=2&on=maybe&at=yesterday&sort=size",
+		map[string]string{"id": "not-a-uuid", "n": "x"},
+		map[string]string{"If-Modified-Since": "yesterday", "X-Count": "11"})
+	p := NewParams(r)
+	require.Equal(t, 20, p.QueryInt("limit", 20, 1, 100))
+	require.Equal(t, 0, p.QueryInt("offset", 0, 0, 100))
+	require.Equal(t, "", p.QueryRequired("q"))
+	require.Equal(t, 1.0, p.QueryFloat("ratio", 1, 0, 1))
+	require.Equal(t, 1.0, p.QueryFloat("ratio2", 1, 0, 1.5))
+	require.False(t, p.QueryBool("on", false))
+	require.True(t, p.QueryTime("at", time.Time{}).IsZero())
+	require.Equal(t, "id", p.QueryOneOf("sort", "id", "id", "name"))
+	require.Equal(t, "", p.PathUUID("id"))
+	require.Equal(t, 0, p.PathInt("n", 1, 10))
+	require.Equal(t, "", p.Path("missing"))
+	require.True(t, p.HeaderTime("If-Modified-Since").IsZero())
+	require.Equal(t, 0, p.HeaderInt("X-Count", 0, 0, 10))
+	p.Add(InHeader, "X-Custom", NewReason("custom").WithCode(CodeInvalid))
+	require.False(t, p.Valid())
+
+	err := p.Err()
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, err.StatusCode())
+	require.Equal(t, http.StatusText(http.StatusBadRequest), err.Error())
+	require.Contains(t, err.StackInfo()[0].Function, "TestParams_Invalid")
+	require.Equal(t, []any{
+		Reason{Code: CodeRange, Message: "must be between 1 and 100", Field: "limit", Value: 500, Meta: map[string]any{"in": InQuery, "min": 1.0, "max": 100.0}},
+		Reason{Code: CodeInvalid, Message: "must be an integer", Field: "offset", Value: "x", Meta: map[string]any{"in": InQuery}},
+		Reason{Code: CodeRequired, Message: "is required", Field: "q", Meta: map[string]any{"in": InQuery}},
+		Reason{Code: CodeInvalid, Message: "must be a number", Field: "ratio", Value: "y", Meta: map[string]any{"in": InQuery}},
+		Reason{Code: CodeRange, Message: "must be between 0 and 1.5", Field: "ratio2", Value: 2.0, Meta: map[string]any{"in": InQuery, "min": 0.0, "max": 1.5}},
+		Reason{Code: CodeInvalid, Message: "must be a boolean", Field: "on", Value: "maybe", Meta: map[string]any{"in": InQuery}},
+		Reason{Code: CodeInvalid, Message: "must be an RFC3339 date-time", Field: "at", Value: "yesterday", Meta: map[string]any{"in": InQuery}},
+		Reason{Code: CodeOneOf, Message: "must be one of id, name", Field: "sort", Value: "size", Meta: map[string]any{"in": InQuery, "allowed": []any{"id", "name"}}},
+		Reason{Code: CodeInvalid, Message: "must be a UUID", Field: "id", Value: "not-a-uuid", Meta: map[string]any{"in": InPath}},
+		Reason{Code: CodeInvalid, Message: "must be an integer", Field: "n", Value: "x", Meta: map[string]any{"in": InPath}},
+		Reason{Code: CodeRequired, Message: "is required", Field: "missing", Meta: map[string]any{"in": InPath}},
+		Reason{Code: CodeInvalid, Message: "must be a HTTP date", Field: "If-Modified-Since", Value: "yesterday", Meta: map[string]any{"in": InHeader}},
+		Reason{Code: CodeRange, Message: "must be between 0 and 10", Field: "X-Count", Value: 11, Meta: map[string]any{"in": InHeader, "min": 0.0, "max": 10.0}},
+		Reason{Code: CodeInvalid, Message: "custom", Field: "X-Custom", Meta: map[string]any{"in": InHeader}},
+	}, err.Reasons())
+}
+
+func TestParams_QueryFloat_NonFinite(t *testing.T) {
+	p := NewParams(newParamsRequest("/things?a=NaN&b=Inf&c=-inf&d=1e400", nil, nil))
+	for _, name := range []string{"a", "b", "c", "d"} {
+		require.Equal(t, 0.5, p.QueryFloat(name, 0.5, math.Inf(-1), math.Inf(1)))
+	}
+	reasons := p.Reasons()
+	require.Len(t, reasons, 4)
+	require.Equal(t, Reason{Code: CodeInvalid, Message: "must be a number", Field: "a", Value: "NaN", Meta: map[string]any{"in": InQuery}}, reasons[0])
+	require.Equal(t, "Inf", reasons[1].Value)
+	require.Equal(t, "1e400", reasons[3].Value)
+}
+
+func TestIsUUID(t *testing.T) {
+	require.True(t, isUUID("123e4567-e89b-12d3-a456-426614174000"))
+	require.True(t, isUUID("123E4567-E89B-12D3-A456-426614174000"))
+	require.False(t, isUUID("123e4567e89b12d3a456426614174000"))
+	require.False(t, isUUID("123e4567-e89b-12d3-a456-42661417400g"))
+	require.False(t, isUUID("123e4567-e89b-12d3-a456_426614174000"))
+}
@@ -0,0 +1,163 @@
+package httperr
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	hdrRange   = "Range"
+	hdrIfRange = "If-Range"
+	bytesUnit  = "bytes"
+)
+
+// MaxRanges is the maximum number of ranges accepted by ParseRange - requests with more ranges are not satisfiable
+//
+// a value of zero means no limit
+var MaxRanges = 100
+
+// ByteRange is a satisfiable byte range (as parsed by ParseRange)
+type ByteRange struct {
+	// Start is the first byte offset
+	Start int64
+	// Length is the number of bytes
+	Length int64
+}
+
+// End returns the last byte offset (inclusive)
+func (br ByteRange) End() int64 {
+	return br.Start + br.Length - 1
+}
+
+// ContentRange returns the Content-Range header value for the range (e.g. "bytes 0-499/1234")
+func (br ByteRange) ContentRange(size int64) string {
+	return bytesUnit + " " + strconv.FormatInt(br.Start, 10) + "-" + strconv.FormatInt(br.End(), 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// ParseRange parses the Range header of a GET (or HEAD) request for a representation of the supplied size
+//
+// returns:
+//   - nil ranges (and no error) if there is no Range header, the method is not GET or HEAD, the header is malformed
+//     or not a bytes range, or the ranges total more than the size (which should be ignored - i.e. the full
+//     representation served)
+//   - the satisfiable ranges (with end positions limited to the size)
+//   - a 416 Requested Range Not Satisfiable error (with Content-Range header "bytes */<size>") if none of the ranges
+//     are satisfiable - or there are more than MaxRanges ranges
+//
+// Note: ParseRange does not evaluate If-Range - use IfRange to check whether the Range header should be used
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-range-requests
+func ParseRange(r *http.Request, size int64) ([]ByteRange, HttpError) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return nil, nil
+	}
+	hdr := r.Header.Get(hdrRange)
+	if hdr == "" {
+		return nil, nil
+	}
+	ranges, ok, satisfiable := parseRange(hdr, size)
+	if !ok {
+		return nil, nil
+	} else if !satisfiable || len(ranges) == 0 || (MaxRanges > 0 && len(ranges) > MaxRanges) {
+		return nil, newError(http.StatusRequestedRangeNotSatisfiable, "", nil, getStackInfo()).
+			SetHeader(hdrContentRange, unsatisfiedContentRange(size))
+	}
+	return ranges, nil
+}
+
+// IfRange reports whether the Range header should be used - i.e. the request has no If-Range header or the
+// If-Range validator matches the current entity tag (strong comparison) or modification time
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-if-range
+func IfRange(r *http.Request, etag string, modTime time.Time) bool {
+	ir := r.Header.Get(hdrIfRange)
+	if ir == "" {
+		return true
+	}
+	if strings.HasPrefix(ir, `"`) || strings.HasPrefix(ir, `W/"`) {
+		if strings.HasPrefix(ir, `W/`) {
+			return false
+		}
+		return matchETag(ir, normalizeETag(etag), false)
+	}
+	t, err := http.ParseTime(ir)
+	return err == nil && !modTime.IsZero() && modTime.Truncate(time.Second).Equal(t)
+}
+
+// parseRange parses a Range header value - ok is false if the header is malformed (or not a bytes range, or the
+// ranges overlap to total more than the size) and satisfiable is false if there are too many ranges
+func parseRange(hdr string, size int64) (ranges []ByteRange, ok bool, satisfiable bool) {
+	unit, specs, found := strings.Cut(hdr, "=")
+	if !found || !strings.EqualFold(strings.TrimSpace(unit), bytesUnit) {
+		return nil, false, false
+	}
+	count := 0
+	for _, spec := range strings.Split(specs, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+		if count++; MaxRanges > 0 && count > MaxRanges {
+			return nil, true, false
+		}
+		first, last, found := strings.Cut(spec, "-")
+		if !found {
+			return nil, false, false
+		}
+		if first == "" {
+			// suffix range
+			n, err := parseRangeInt(last)
+			if err != nil {
+				return nil, false, false
+			}
+			if n > size {
+				n = size
+			}
+			if n > 0 {
+				ranges = append(ranges, ByteRange{Start: size - n, Length: n})
+			}
+			continue
+		}
+		start, err := parseRangeInt(first)
+		if err != nil {
+			return nil, false, false
+		}
+		end := size - 1
+		if last != "" {
+			if end, err = parseRangeInt(last); err != nil || end < start {
+				return nil, false, false
+			}
+			if end >= size {
+				end = size - 1
+			}
+		}
+		if start < size {
+			ranges = append(ranges, ByteRange{Start: start, Length: end - start + 1})
+		}
+	}
+	if count == 0 {
+		return nil, false, false
+	}
+	sum := int64(0)
+	for _, br := range ranges {
+		if sum += br.Length; sum > size {
+			// overlapping ranges (e.g. "bytes=0-,0-") - ignored, in the same way as http.ServeContent
+			return nil, false, false
+		}
+	}
+	return ranges, true, true
+}
+
+// parseRangeInt parses a range position - which must be digits only (no sign)
+func parseRangeInt(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, strconv.ErrSyntax
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func unsatisfiedContentRange(size int64) string {
+	return bytesUnit + " */" + strconv.FormatInt(size, 10)
+}
@@ -0,0 +1,153 @@
+package httperr
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	testCases := []struct {
+		method string
+		header string
+		size   int64
+		expect []ByteRange
+		err    bool
+	}{
+		{header: "", size: 100},
+		{method: http.MethodPost, header: "bytes=0-9", size: 100},
+		{header: "bytes=0-9", size: 100, expect: []ByteRange{{0, 10}}},
+		{method: http.MethodHead, header: "bytes=0-9", size: 100, expect: []ByteRange{{0, 10}}},
+		{header: "bytes=90-", size: 100, expect: []ByteRange{{90, 10}}},
+		{header: "bytes=-10", size: 100, expect: []ByteRange{{90, 10}}},
+		{header: "bytes=-200", size: 100, expect: []ByteRange{{0, 100}}},
+		{header: "bytes=50-200", size: 100, expect: []ByteRange{{50, 50}}},
+		{header: "Bytes = 0-0, 10-19 ,, -5", size: 100, expect: []ByteRange{{0, 1}, {10, 10}, {95, 5}}},
+		{header: "bytes=100-, 0-4", size: 100, expect: []ByteRange{{0, 5}}},
+		{header: "bytes=100-", size: 100, err: true},
+		{header: "bytes=100-200", size: 100, err: true},
+		{header: "bytes=-0", size: 100, err: true},
+		{header: "bytes=0-", size: 0, err: true},
+		{header: "bytes=-5", size: 0, err: true},
+		{header: "items=0-9", size: 100},
+		{header: "bytes", size: 100},
+		{header: "bytes=", size: 100},
+		{header: "bytes=5", size: 100},
+		{header: "bytes=9-5", size: 100},
+		{header: "bytes=a-5", size: 100},
+		{header: "bytes=0-b", size: 100},
+		{header: "bytes=-x", size: 100},
+		{header: "bytes=+1-5", size: 100},
+		{header: "bytes=0-5, x", size: 100},
+	}
+	for i, tc := range testCases {
+		method := tc.method
+		if method == "" {
+			method = http.MethodGet
+		}
+		r := httptest.NewRequest(method, "/", nil)
+		if tc.header != "" {
+			r.Header.Set(hdrRange, tc.header)
+		}
+		ranges, err := ParseRange(r, tc.size)
+		if tc.err {
+			require.Error(t, err, i)
+			require.Nil(t, ranges, i)
+			require.Equal(t, http.StatusRequestedRangeNotSatisfiable, err.StatusCode(), i)
+			var cre ContentRangeError
+			require.True(t, errors.As(err, &cre), i)
+			cr, ok := cre.ContentRange()
+			require.True(t, ok, i)
+			require.Equal(t, unsatisfiedContentRange(tc.size), cr, i)
+			require.Contains(t, err.StackInfo()[0].Function, "TestParseRange", i)
+		} else {
+			require.Nil(t, err, i)
+			require.Equal(t, tc.expect, ranges, i)
+		}
+	}
+}
+
+func TestParseRange_MaxRanges(t *testing.T) {
+	defer func(old int) {
+		MaxRanges = old
+	}(MaxRanges)
+	MaxRanges = 2
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(hdrRange, "bytes=0-1,2-3,4-5")
+	_, err := ParseRange(r, 100)
+	require.Error(t, err)
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, err.StatusCode())
+
+	MaxRanges = 0
+	ranges, err := ParseRange(r, 100)
+	require.Nil(t, err)
+	require.Len(t, ranges, 3)
+}
+
+func TestParseRange_Overlapping(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(hdrRange, "bytes="+strings.Repeat("0-,", 99)+"0-")
+	ranges, err := ParseRange(r, 1<<30)
+	require.Nil(t, err)
+	require.Nil(t, ranges)
+
+	r.Header.Set(hdrRange, "bytes=0-59,50-99")
+	ranges, err = ParseRange(r, 100)
+	require.Nil(t, err)
+	require.Nil(t, ranges)
+
+	r.Header.Set(hdrRange, "bytes=0-49,50-99")
+	ranges, err = ParseRange(r, 100)
+	require.Nil(t, err)
+	require.Equal(t, []ByteRange{{Start: 0, Length: 50}, {Start: 50, Length: 50}}, ranges)
+}
+
+func TestParseRange_Write(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(hdrRange, "bytes=500-")
+	_, err := ParseRange(r, 100)
+	require.Error(t, err)
+	w := httptest.NewRecorder()
+	err.(RequestWriter).WriteRequest(w, r)
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	require.Equal(t, "bytes */100", w.Header().Get(hdrContentRange))
+}
+
+func TestByteRange(t *testing.T) {
+	br := ByteRange{Start: 10, Length: 5}
+	require.Equal(t, int64(14), br.End())
+	require.Equal(t, "bytes 10-14/100", br.ContentRange(100))
+}
+
+func TestIfRange(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 500, time.UTC)
+	testCases := []struct {
+		header string
+		etag   string
+		expect bool
+	}{
+		{header: "", expect: true},
+		{header: `"a"`, etag: `"a"`, expect: true},
+		{header: `"a"`, etag: "a", expect: true},
+		{header: `"b"`, etag: `"a"`, expect: false},
+		{header: `W/"a"`, etag: `"a"`, expect: false},
+		{header: `"a"`, etag: `W/"a"`, expect: false},
+		{header: modTime.Format(http.TimeFormat), expect: true},
+		{header: modTime.Add(-time.Hour).Format(http.TimeFormat), expect: false},
+		{header: "garbage", expect: false},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			r.Header.Set(hdrIfRange, tc.header)
+		}
+		require.Equal(t, tc.expect, IfRange(r, tc.etag, modTime), i)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(hdrIfRange, modTime.Format(http.TimeFormat))
+	require.False(t, IfRange(r, "", time.Time{}))
+}
@@ -0,0 +1,139 @@
+package httperr
+
+import (
+	"encoding/json"
+	"maps"
+)
+
+// Reason is a structured reason for an error
+//
+// reasons of any type can be added to a HttpError (see HttpError.AddReason) - but using Reason gives
+// consistent reasons that clients can reliably decode (see AsReason)
+type Reason struct {
+	// Code is an (optional) machine-readable code for the reason (e.g. "required")
+	Code string `json:"code,omitempty"`
+	// Message is the human-readable message for the reason
+	Message string `json:"message"`
+	// Field is the (optional) name or path of the field the reason relates to (e.g. "items[0].name")
+	Field string `json:"field,omitempty"`
+	// Pointer is the (optional) JSON pointer of the location the reason relates to (e.g. "/items/0/name")
+	Pointer string `json:"pointer,omitempty"`
+	// Value is the (optional) rejected value
+	Value any `json:"value,omitempty"`
+	// Meta is (optional) additional metadata for the reason
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// NewReason creates a new Reason with the supplied message
+func NewReason(message string) Reason {
+	return Reason{Message: message}
+}
+
+// FieldReason creates a new Reason for the supplied field with the supplied message
+func FieldReason(field string, message string) Reason {
+	return Reason{Field: field, Message: message}
+}
+
+// WithCode returns the reason with the supplied code
+func (r Reason) WithCode(code string) Reason {
+	r.Code = code
+	return r
+}
+
+// WithField returns the reason with the supplied field
+func (r Reason) WithField(field string) Reason {
+	r.Field = field
+	return r
+}
+
+// WithPointer returns the reason with the supplied JSON pointer
+func (r Reason) WithPointer(pointer string) Reason {
+	r.Pointer = pointer
+	return r
+}
+
+// WithValue returns the reason with the supplied rejected value
+func (r Reason) WithValue(value any) Reason {
+	r.Value = value
+	return r
+}
+
+// WithMeta returns the reason with the supplied metadata key and value added
+func (r Reason) WithMeta(key string, value any) Reason {
+	meta := make(map[string]any, len(r.Meta)+1)
+	maps.Copy(meta, r.Meta)
+	meta[key] = value
+	r.Meta = meta
+	return r
+}
+
+// String returns the reason message (prefixed by the field, if any)
+func (r Reason) String() string {
+	if r.Field != "" {
+		return r.Field + ": " + r.Message
+	}
+	return r.Message
+}
+
+// AsReason converts a reason value (e.g. from HttpError.Reasons) to a Reason
+//
+// a Reason (or *Reason) is returned as-is and a string is used as the message - any other value
+// (e.g. a map decoded from a response body) is converted via json and must have a message or code
+func AsReason(v any) (Reason, bool) {
+	switch vt := v.(type) {
+	case Reason:
+		return vt, true
+	case *Reason:
+		if vt != nil {
+			return *vt, true
+		}
+		return Reason{}, false
+	case string:
+		return NewReason(vt), true
+	case nil:
+		return Reason{}, false
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Reason{}, false
+	}
+	var r Reason
+	if err = json.Unmarshal(data, &r); err != nil || (r.Message == "" && r.Code == "") {
+		return Reason{}, false
+	}
+	return r, true
+}
+
+func appendReason(dst []byte, r Reason) (result []byte, err error) {
+	dst = append(dst, '{')
+	if r.Code != "" {
+		dst = append(dst, `"code":`...)
+		dst = appendString(dst, r.Code)
+		dst = append(dst, ',')
+	}
+	dst = append(dst, `"message":`...)
+	dst = appendString(dst, r.Message)
+	if r.Field != "" {
+		dst = append(dst, `,"field":`...)
+		dst = appendString(dst, r.Field)
+	}
+	if r.Pointer != "" {
+		dst = append(dst, `,"pointer":`...)
+		dst = appendString(dst, r.Pointer)
+	}
+	if r.Value != nil {
+		dst = append(dst, `,"value":`...)
+		if dst, err = appendValue(dst, r.Value); err != nil {
+			return dst, err
+		}
+	}
+	if len(r.Meta) > 0 {
+		dst = append(dst, `,"meta":`...)
+		data, err := json.Marshal(r.Meta)
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, data...)
+	}
+	return append(dst, '}'), nil
+}
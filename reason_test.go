@@ -0,0 +1,103 @@
+package httperr
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReason_Builders(t *testing.T) {
+	r := NewReason("must not be empty").
+		WithCode("required").
+		WithField("items[0].name").
+		WithPointer("/items/0/name").
+		WithValue("").
+		WithMeta("min", 1)
+	require.Equal(t, Reason{
+		Code:    "required",
+		Message: "must not be empty",
+		Field:   "items[0].name",
+		Pointer: "/items/0/name",
+		Value:   "",
+		Meta:    map[string]any{"min": 1},
+	}, r)
+	r2 := r.WithMeta("max", 10)
+	require.Len(t, r.Meta, 1)
+	require.Len(t, r2.Meta, 2)
+	require.Equal(t, "items[0].name: must not be empty", r.String())
+	require.Equal(t, "whoops", NewReason("whoops").String())
+	require.Equal(t, Reason{Field: "name", Message: "required"}, FieldReason("name", "required"))
+}
+
+func TestAppendReason(t *testing.T) {
+	testCases := []Reason{
+		NewReason("simple"),
+		NewReason("").WithCode("code"),
+		FieldReason("name", "required").WithCode("required").WithPointer("/name"),
+		FieldReason("age", "out of range").WithValue(200).WithMeta("min", 0).WithMeta("max", 150),
+		FieldReason("tags", "invalid").WithValue([]string{"a", "b"}),
+		FieldReason("zero", "zero value").WithValue(0),
+	}
+	for _, tc := range testCases {
+		t.Run(tc.String(), func(t *testing.T) {
+			expect, err := json.Marshal(tc)
+			require.NoError(t, err)
+			actual, err := appendValue(nil, tc)
+			require.NoError(t, err)
+			require.Equal(t, string(expect), string(actual))
+			actual, err = appendValue(nil, &tc)
+			require.NoError(t, err)
+			require.Equal(t, string(expect), string(actual))
+		})
+	}
+	actual, err := appendValue(nil, (*Reason)(nil))
+	require.NoError(t, err)
+	require.Equal(t, "null", string(actual))
+	_, err = appendValue(nil, NewReason("bad").WithValue(make(chan int)))
+	require.Error(t, err)
+	_, err = appendValue(nil, NewReason("bad").WithMeta("x", func() {}))
+	require.Error(t, err)
+}
+
+func TestAsReason(t *testing.T) {
+	r := FieldReason("name", "required")
+	rr, ok := AsReason(r)
+	require.True(t, ok)
+	require.Equal(t, r, rr)
+	rr, ok = AsReason(&r)
+	require.True(t, ok)
+	require.Equal(t, r, rr)
+	_, ok = AsReason((*Reason)(nil))
+	require.False(t, ok)
+	rr, ok = AsReason("just a message")
+	require.True(t, ok)
+	require.Equal(t, NewReason("just a message"), rr)
+	_, ok = AsReason(nil)
+	require.False(t, ok)
+	rr, ok = AsReason(map[string]any{"code": "x", "field": "f", "meta": map[string]any{"a": 1.0}})
+	require.True(t, ok)
+	require.Equal(t, Reason{Code: "x", Field: "f", Meta: map[string]any{"a": 1.0}}, rr)
+	_, ok = AsReason(testReason{"foo", "bar"})
+	require.False(t, ok)
+	_, ok = AsReason(make(chan int))
+	require.False(t, ok)
+	_, ok = AsReason(42)
+	require.False(t, ok)
+}
+
+func TestReason_RoundTrip(t *testing.T) {
+	w := httptest.NewRecorder()
+	DefaultErrorWriter.WriteError(NewBadRequestError("invalid").AddReasons(
+		FieldReason("name", "required").WithCode("required"),
+		"plain reason",
+	), w)
+	e := FromResponse(w.Result())
+	require.Len(t, e.Reasons(), 2)
+	r, ok := AsReason(e.Reasons()[0])
+	require.True(t, ok)
+	require.Equal(t, FieldReason("name", "required").WithCode("required"), r)
+	r, ok = AsReason(e.Reasons()[1])
+	require.True(t, ok)
+	require.Equal(t, NewReason("plain reason"), r)
+}
@@ -0,0 +1,164 @@
+package httperr
+
+import (
+	"errors"
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RedirectPolicy determines which redirect locations are allowed to be written for 3xx errors
+//
+// this allows redirects to be safely built from user supplied locations (e.g. a `return_to` parameter)
+type RedirectPolicy struct {
+	// AllowedSchemes are the schemes allowed for absolute locations
+	//
+	// if empty, only "http" and "https" are allowed (so that, for example, `javascript:` locations are always rejected)
+	AllowedSchemes []string
+	// AllowedHosts are the hosts allowed for absolute (and scheme relative, e.g. "//example.com/") locations
+	//
+	// if empty, only the request host is allowed - a host starting with "." allows any sub-domain (e.g. ".example.com")
+	AllowedHosts []string
+	// Fallback is the location written when a location is rejected by the policy
+	//
+	// if empty, the Location header is not written for rejected locations
+	Fallback string
+}
+
+// DefaultRedirectPolicy is the redirect policy used when writing the Location header for 3xx errors
+//
+// if this is set to nil, locations are not checked
+var DefaultRedirectPolicy = &RedirectPolicy{}
+
+// ErrRedirectNotAllowed is the error returned by RedirectPolicy.Check for locations that are not allowed
+var ErrRedirectNotAllowed = errors.New("redirect location not allowed")
+
+// Check checks whether the location is allowed by the policy
+//
+// relative locations (e.g. "/home", "../other") are allowed - except paths starting with "//" (e.g. "///evil.com"),
+// which browsers follow as scheme relative
+//
+// when the policy has no AllowedHosts, absolute locations are only allowed to the same host as the request
+// (and are rejected if the request is nil)
+func (rp *RedirectPolicy) Check(location string, r *http.Request) error {
+	if strings.ContainsFunc(location, func(r rune) bool { return r < 0x20 || r == 0x7f || r == '\\' }) {
+		return ErrRedirectNotAllowed
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return ErrRedirectNotAllowed
+	}
+	if u.Host == "" && strings.HasPrefix(u.Path, "//") {
+		// e.g. "///evil.com" has no host - but browsers treat it as scheme relative
+		return ErrRedirectNotAllowed
+	}
+	if u.Scheme != "" {
+		schemes := rp.AllowedSchemes
+		if len(schemes) == 0 {
+			schemes = []string{"http", "https"}
+		}
+		if !slices.ContainsFunc(schemes, func(s string) bool { return strings.EqualFold(s, u.Scheme) }) {
+			return ErrRedirectNotAllowed
+		}
+		if u.Opaque != "" || u.Host == "" {
+			return ErrRedirectNotAllowed
+		}
+	}
+	if u.Host != "" {
+		host := strings.ToLower(u.Hostname())
+		if len(rp.AllowedHosts) == 0 {
+			if r == nil || !strings.EqualFold(host, requestHostname(r)) {
+				return ErrRedirectNotAllowed
+			}
+		} else if !slices.ContainsFunc(rp.AllowedHosts, func(allowed string) bool {
+			allowed = strings.ToLower(allowed)
+			if strings.HasPrefix(allowed, ".") {
+				return strings.HasSuffix(host, allowed) || host == allowed[1:]
+			}
+			return host == allowed
+		}) {
+			return ErrRedirectNotAllowed
+		}
+	}
+	return nil
+}
+
+func requestHostname(r *http.Request) string {
+	if r.Host == "" && r.URL != nil {
+		return r.URL.Hostname()
+	}
+	return (&url.URL{Host: r.Host}).Hostname()
+}
+
+func isRedirectStatus(status int) bool {
+	return status >= http.StatusMultipleChoices && status < http.StatusBadRequest && status != http.StatusNotModified
+}
+
+// redirectLocation checks (against DefaultRedirectPolicy) and resolves the Location header for 3xx statuses
+//
+// returns the final location (or empty string if there is no location)
+func redirectLocation(hdrs http.Header, status int, r *http.Request) string {
+	location := hdrs.Get(hdrLocation)
+	if location == "" || !isRedirectStatus(status) {
+		return ""
+	}
+	if DefaultRedirectPolicy != nil && DefaultRedirectPolicy.Check(location, r) != nil {
+		if location = DefaultRedirectPolicy.Fallback; location == "" {
+			hdrs.Del(hdrLocation)
+			return ""
+		}
+	}
+	if r != nil && r.URL != nil {
+		location = resolveLocation(location, r.URL.Path)
+	}
+	hdrs.Set(hdrLocation, location)
+	return location
+}
+
+// resolveLocation resolves a relative location against the request path (in the same way as http.Redirect)
+func resolveLocation(location string, requestPath string) string {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return location
+	}
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	if location == "" || location[0] != '/' {
+		dir, _ := path.Split(requestPath)
+		location = dir + location
+	}
+	query := ""
+	if i := strings.IndexByte(location, '?'); i != -1 {
+		location, query = location[:i], location[i:]
+	}
+	trailing := strings.HasSuffix(location, "/")
+	location = path.Clean(location)
+	if trailing && !strings.HasSuffix(location, "/") {
+		location += "/"
+	}
+	return location + query
+}
+
+const textHtml = "text/html; charset=utf-8"
+
+// writeRedirect writes a redirect response (in the same way as http.Redirect) - with a small
+// html body for GET requests, headers only for HEAD and no body for other methods
+func writeRedirect(w http.ResponseWriter, r *http.Request, status int, location string) {
+	hdrs := w.Header()
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		body := `<a href="` + html.EscapeString(location) + `">` + http.StatusText(status) + "</a>.\n"
+		hdrs.Set(hdrContentType, textHtml)
+		hdrs.Set(hdrContentLength, strconv.Itoa(len(body)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(body))
+		}
+		return
+	}
+	w.WriteHeader(status)
+}
@@ -0,0 +1,173 @@
+package httperr
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectPolicy_Check(t *testing.T) {
+	testCases := []struct {
+		location string
+		policy   *RedirectPolicy
+		noReq    bool
+		expectOk bool
+	}{
+		{location: "/home", policy: &RedirectPolicy{}, expectOk: true},
+		{location: "../other?a=b", policy: &RedirectPolicy{}, expectOk: true},
+		{location: "https://example.com/home", policy: &RedirectPolicy{}, expectOk: true},
+		{location: "HTTP://example.com/home", policy: &RedirectPolicy{}, expectOk: true},
+		{location: "https://EXAMPLE.com:8443/home", policy: &RedirectPolicy{}, expectOk: true},
+		{location: "https://evil.com/", policy: &RedirectPolicy{}},
+		{location: "//evil.com/", policy: &RedirectPolicy{}},
+		{location: "//example.com/", policy: &RedirectPolicy{}, expectOk: true},
+		{location: "///evil.com", policy: &RedirectPolicy{}},
+		{location: "////evil.com/x", policy: &RedirectPolicy{}},
+		{location: "///evil.com", policy: &RedirectPolicy{}, noReq: true},
+		{location: "////evil.com/x", policy: &RedirectPolicy{AllowedHosts: []string{"example.com"}}},
+		{location: "https://example.com/home", policy: &RedirectPolicy{}, noReq: true},
+		{location: "/home", policy: &RedirectPolicy{}, noReq: true, expectOk: true},
+		{location: "javascript:alert(1)", policy: &RedirectPolicy{}},
+		{location: "JavaScript:alert(1)", policy: &RedirectPolicy{}},
+		{location: "data:text/html,<script>", policy: &RedirectPolicy{}},
+		{location: "http:example.com", policy: &RedirectPolicy{}},
+		{location: "/\\evil.com", policy: &RedirectPolicy{}},
+		{location: "/home\r\nX-Foo: bar", policy: &RedirectPolicy{}},
+		{location: "ftp://example.com/file", policy: &RedirectPolicy{}},
+		{location: "ftp://example.com/file", policy: &RedirectPolicy{AllowedSchemes: []string{"ftp"}}, expectOk: true},
+		{location: "https://example.com/home", policy: &RedirectPolicy{AllowedHosts: []string{"example.com"}}, expectOk: true},
+		{location: "https://evil.com/home", policy: &RedirectPolicy{AllowedHosts: []string{"example.com"}}},
+		{location: "//evil.com/home", policy: &RedirectPolicy{AllowedHosts: []string{"example.com"}}},
+		{location: "https://example.com.evil.com/", policy: &RedirectPolicy{AllowedHosts: []string{"example.com"}}},
+		{location: "https://api.example.com/", policy: &RedirectPolicy{AllowedHosts: []string{".example.com"}}, expectOk: true},
+		{location: "https://example.com/", policy: &RedirectPolicy{AllowedHosts: []string{".example.com"}}, expectOk: true},
+		{location: "https://badexample.com/", policy: &RedirectPolicy{AllowedHosts: []string{".example.com"}}},
+		{location: "https://EXAMPLE.com:8443/", policy: &RedirectPolicy{AllowedHosts: []string{"example.com"}}, expectOk: true},
+		{location: "/home", policy: &RedirectPolicy{AllowedHosts: []string{"example.com"}}, expectOk: true},
+		{location: "https://example.com/home", policy: &RedirectPolicy{AllowedHosts: []string{"example.com"}}, noReq: true, expectOk: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.location, func(t *testing.T) {
+			var r *http.Request
+			if !tc.noReq {
+				r = httptest.NewRequest(http.MethodGet, "/", nil)
+			}
+			err := tc.policy.Check(tc.location, r)
+			if tc.expectOk {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrRedirectNotAllowed)
+			}
+		})
+	}
+}
+
+func TestResolveLocation(t *testing.T) {
+	testCases := []struct {
+		location    string
+		requestPath string
+		expect      string
+	}{
+		{location: "/home", requestPath: "/a/b", expect: "/home"},
+		{location: "other", requestPath: "/a/b", expect: "/a/other"},
+		{location: "../other?x=1", requestPath: "/a/b/c", expect: "/a/other?x=1"},
+		{location: "sub/", requestPath: "/a/", expect: "/a/sub/"},
+		{location: "other", requestPath: "", expect: "/other"},
+		{location: "https://example.com/x", requestPath: "/a/b", expect: "https://example.com/x"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.location, func(t *testing.T) {
+			require.Equal(t, tc.expect, resolveLocation(tc.location, tc.requestPath))
+		})
+	}
+}
+
+func TestDefaultErrorWriter_Redirects(t *testing.T) {
+	ew := DefaultErrorWriter.(RequestErrorWriter)
+	t.Run("GET", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(NewFoundError("", "../login?a=b&c=d"), w, httptest.NewRequest(http.MethodGet, "/a/b/c", nil))
+		require.Equal(t, http.StatusFound, w.Result().StatusCode)
+		require.Equal(t, "/a/login?a=b&c=d", w.Header().Get("Location"))
+		require.Equal(t, textHtml, w.Header().Get("Content-Type"))
+		require.Equal(t, "<a href=\"/a/login?a=b&amp;c=d\">Found</a>.\n", w.Body.String())
+	})
+	t.Run("HEAD", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(NewSeeOtherError("", "/home"), w, httptest.NewRequest(http.MethodHead, "/", nil))
+		require.Equal(t, http.StatusSeeOther, w.Result().StatusCode)
+		require.Equal(t, "/home", w.Header().Get("Location"))
+		require.Equal(t, "31", w.Header().Get("Content-Length"))
+		require.Empty(t, w.Body.Bytes())
+	})
+	t.Run("POST", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(NewSeeOtherError("", "/home"), w, httptest.NewRequest(http.MethodPost, "/", nil))
+		require.Equal(t, http.StatusSeeOther, w.Result().StatusCode)
+		require.Equal(t, "/home", w.Header().Get("Location"))
+		require.Empty(t, w.Body.Bytes())
+	})
+	t.Run("no request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewTemporaryRedirectError("", "somewhere"), w)
+		require.Equal(t, http.StatusTemporaryRedirect, w.Result().StatusCode)
+		require.Equal(t, "somewhere", w.Header().Get("Location"))
+		require.Equal(t, applicationJson, w.Header().Get("Content-Type"))
+	})
+	t.Run("rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(NewFoundError("", "javascript:alert(1)"), w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusFound, w.Result().StatusCode)
+		require.Empty(t, w.Header().Get("Location"))
+		require.Equal(t, applicationJson, w.Header().Get("Content-Type"))
+	})
+	t.Run("rejected (with fallback)", func(t *testing.T) {
+		DefaultRedirectPolicy = &RedirectPolicy{AllowedHosts: []string{"example.com"}, Fallback: "/"}
+		defer func() {
+			DefaultRedirectPolicy = &RedirectPolicy{}
+		}()
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(NewFoundError("", "https://evil.com/"), w, httptest.NewRequest(http.MethodGet, "/a/b", nil))
+		require.Equal(t, http.StatusFound, w.Result().StatusCode)
+		require.Equal(t, "/", w.Header().Get("Location"))
+	})
+	t.Run("rejected (no request)", func(t *testing.T) {
+		for _, location := range []string{"///evil.com", "////evil.com/x", "//evil.com/", "https://evil.com/"} {
+			w := httptest.NewRecorder()
+			NewFoundError("", location).Write(w)
+			require.Equal(t, http.StatusFound, w.Result().StatusCode)
+			require.Empty(t, w.Header().Get("Location"))
+		}
+	})
+	t.Run("rejected (other host)", func(t *testing.T) {
+		for _, location := range []string{"https://evil.com/", "//evil.com/"} {
+			w := httptest.NewRecorder()
+			ew.WriteRequestError(NewFoundError("", location), w, httptest.NewRequest(http.MethodGet, "/", nil))
+			require.Equal(t, http.StatusFound, w.Result().StatusCode)
+			require.Empty(t, w.Header().Get("Location"))
+		}
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(NewFoundError("", "https://example.com/home"), w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, "https://example.com/home", w.Header().Get("Location"))
+	})
+	t.Run("no policy", func(t *testing.T) {
+		DefaultRedirectPolicy = nil
+		defer func() {
+			DefaultRedirectPolicy = &RedirectPolicy{}
+		}()
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(NewFoundError("", "custom:thing"), w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, "custom:thing", w.Header().Get("Location"))
+	})
+	t.Run("no default writer", func(t *testing.T) {
+		DefaultErrorWriter = nil
+		defer func() {
+			DefaultErrorWriter = &errorWriter{}
+		}()
+		w := httptest.NewRecorder()
+		NewFoundError("", "javascript:alert(1)").(RequestWriter).WriteRequest(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusFound, w.Result().StatusCode)
+		require.Empty(t, w.Header().Get("Location"))
+	})
+}
@@ -0,0 +1,181 @@
+package httperr
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfter is a Retry-After value - either a delay or a date
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-retry-after
+type RetryAfter struct {
+	// Delay is the delay after which to retry
+	Delay time.Duration
+	// Date is the date after which to retry (used when Delay is zero)
+	Date time.Time
+}
+
+// IsZero determines whether the RetryAfter has no delay or date
+func (ra RetryAfter) IsZero() bool {
+	return ra.Delay <= 0 && ra.Date.IsZero()
+}
+
+// Duration returns the delay (or, for a date, the duration until that date) - never less than zero
+func (ra RetryAfter) Duration() time.Duration {
+	d := ra.Delay
+	if d <= 0 && !ra.Date.IsZero() {
+		d = ra.Date.Sub(timeNow())
+	}
+	return max(d, 0)
+}
+
+// Seconds returns the delay (or, for a date, the duration until that date) in whole seconds - rounded up
+func (ra RetryAfter) Seconds() int64 {
+	d := ra.Duration()
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// String returns the Retry-After header value - i.e. delay-seconds or an HTTP-date
+func (ra RetryAfter) String() string {
+	if ra.Delay <= 0 && !ra.Date.IsZero() {
+		return ra.Date.UTC().Format(http.TimeFormat)
+	}
+	return strconv.FormatInt(ra.Seconds(), 10)
+}
+
+// ParseRetryAfter parses a Retry-After header value (either delay-seconds or an HTTP-date)
+func ParseRetryAfter(value string) (RetryAfter, bool) {
+	value = strings.TrimSpace(value)
+	if secs, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return RetryAfter{Delay: time.Duration(secs) * time.Second}, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return RetryAfter{Date: date}, true
+	}
+	return RetryAfter{}, false
+}
+
+const hdrRetryAfter = "Retry-After"
+
+// DefaultRetryableStatuses are the status codes for which errors are, by default, retryable
+//
+// an individual error can override this using RetryableSetter.WithRetryable
+var DefaultRetryableStatuses = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultTimeoutStatuses are the status codes for which errors are, by default, timeouts
+//
+// an individual error can override this using RetryableSetter.WithTimeout
+var DefaultTimeoutStatuses = []int{
+	http.StatusRequestTimeout,
+	http.StatusGatewayTimeout,
+}
+
+// RetryAfterSetter is a HttpError with a Retry-After
+//
+// implemented by all errors created by this package (but not part of HttpError) - for example...
+//
+//	if ras, ok := err.(httperr.RetryAfterSetter); ok {
+//		ras.WithRetryAfter(30 * time.Second)
+//	}
+type RetryAfterSetter interface {
+	HttpError
+	RetryAfterError
+	// WithRetryAfter sets the Retry-After (as a delay) for the error
+	//
+	// the Retry-After header (as delay-seconds) is added and the delay is written to the response body by the DefaultErrorWriter
+	WithRetryAfter(delay time.Duration) RetryAfterSetter
+	// WithRetryAt sets the Retry-After (as a date) for the error
+	//
+	// the Retry-After header (as an HTTP-date) is added and the remaining delay is written to the response body by the DefaultErrorWriter
+	WithRetryAt(date time.Time) RetryAfterSetter
+}
+
+var _ RetryAfterSetter = (*httpError)(nil)
+
+// RetryableError is an error that can declare whether a retry is safe and worth it
+type RetryableError interface {
+	error
+	// Retryable returns whether the failed request can be retried
+	Retryable() bool
+}
+
+// RetryableSetter is a HttpError with retryable and timeout classification
+//
+// implemented by all errors created by this package (but not part of HttpError) - for example...
+//
+//	if rs, ok := err.(httperr.RetryableSetter); ok {
+//		rs.WithRetryable(false)
+//	}
+type RetryableSetter interface {
+	HttpError
+	RetryableError
+	// Temporary returns whether the error is temporary (same as Retryable)
+	Temporary() bool
+	// Timeout returns whether the error is a timeout
+	//
+	// unless overridden by WithTimeout, this is derived from the status code (see DefaultTimeoutStatuses)
+	Timeout() bool
+	// WithRetryable overrides whether the error is retryable
+	//
+	// unless overridden, this is derived from the status code (see DefaultRetryableStatuses) - retryable
+	// errors have a retryable hint written to the response body by the DefaultErrorWriter
+	WithRetryable(retryable bool) RetryableSetter
+	// WithTimeout overrides whether the error is a timeout
+	WithTimeout(timeout bool) RetryableSetter
+}
+
+var _ RetryableSetter = (*httpError)(nil)
+
+// IsRetryable determines whether the error is retryable
+//
+// the error chain is searched for a RetryableError (including HttpError) - if there is none, any StatusError
+// in the chain is checked against DefaultRetryableStatuses.  Otherwise, the error is not retryable
+func IsRetryable(err error) bool {
+	var re RetryableError
+	if errors.As(err, &re) {
+		return re.Retryable()
+	}
+	var se StatusError
+	if errors.As(err, &se) {
+		return slices.Contains(DefaultRetryableStatuses, se.StatusCode())
+	}
+	return false
+}
+
+func (e *httpError) Retryable() bool {
+	if e.retryable != nil {
+		return *e.retryable
+	}
+	return slices.Contains(DefaultRetryableStatuses, e.status)
+}
+
+func (e *httpError) Temporary() bool {
+	return e.Retryable()
+}
+
+func (e *httpError) Timeout() bool {
+	if e.timeout != nil {
+		return *e.timeout
+	}
+	return slices.Contains(DefaultTimeoutStatuses, e.status)
+}
+
+func (e *httpError) WithRetryable(retryable bool) RetryableSetter {
+	e.retryable = &retryable
+	return e
+}
+
+func (e *httpError) WithTimeout(timeout bool) RetryableSetter {
+	e.timeout = &timeout
+	return e
+}
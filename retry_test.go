@@ -0,0 +1,141 @@
+package httperr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	timeNow = func() time.Time {
+		return now
+	}
+	defer func() {
+		timeNow = time.Now
+	}()
+	ra := RetryAfter{}
+	require.True(t, ra.IsZero())
+	require.Equal(t, "0", ra.String())
+
+	ra = RetryAfter{Delay: 1500 * time.Millisecond}
+	require.False(t, ra.IsZero())
+	require.Equal(t, 1500*time.Millisecond, ra.Duration())
+	require.Equal(t, int64(2), ra.Seconds())
+	require.Equal(t, "2", ra.String())
+
+	ra = RetryAfter{Date: now.Add(time.Minute)}
+	require.False(t, ra.IsZero())
+	require.Equal(t, time.Minute, ra.Duration())
+	require.Equal(t, int64(60), ra.Seconds())
+	require.Equal(t, "Thu, 02 Jan 2025 03:05:05 GMT", ra.String())
+
+	ra = RetryAfter{Date: now.Add(-time.Minute)}
+	require.Equal(t, time.Duration(0), ra.Duration())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	ra, ok := ParseRetryAfter("120")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, ra.Delay)
+	ra, ok = ParseRetryAfter("Thu, 02 Jan 2025 03:05:05 GMT")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2025, 1, 2, 3, 5, 5, 0, time.UTC), ra.Date)
+	_, ok = ParseRetryAfter("-1")
+	require.False(t, ok)
+	_, ok = ParseRetryAfter("soon")
+	require.False(t, ok)
+}
+
+func TestError_WithRetryAfter(t *testing.T) {
+	e := NewServiceUnavailableError("", nil).(RetryAfterSetter)
+	_, ok := e.RetryAfter()
+	require.False(t, ok)
+	_ = e.WithRetryAfter(30 * time.Second)
+	ra, ok := e.RetryAfter()
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, ra.Delay)
+	require.Equal(t, "30", e.(HeaderSetter).Header().Get("Retry-After"))
+	_ = e.WithRetryAfter(0)
+	_, ok = e.RetryAfter()
+	require.False(t, ok)
+	require.Empty(t, e.(HeaderSetter).Header().Get("Retry-After"))
+}
+
+func TestError_WithRetryAt(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600))
+	e := NewMovedPermanentlyError("", "/elsewhere").(RetryAfterSetter).WithRetryAt(at)
+	ra, ok := e.RetryAfter()
+	require.True(t, ok)
+	require.Equal(t, at, ra.Date)
+	require.Equal(t, "Thu, 02 Jan 2025 02:04:05 GMT", e.(HeaderSetter).Header().Get("Retry-After"))
+}
+
+func TestDefaultErrorWriter_RetryAfter(t *testing.T) {
+	w := httptest.NewRecorder()
+	DefaultErrorWriter.WriteError(NewTooManyRequestsRetryError("slow down", 90*time.Second), w)
+	require.Equal(t, http.StatusTooManyRequests, w.Result().StatusCode)
+	require.Equal(t, "90", w.Header().Get("Retry-After"))
+	body, err := unmarshalBody(w.Result().Body)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{ptyError: "slow down", "$retryAfter": float64(90), "$retryable": true}, body)
+}
+
+func TestError_Retryable(t *testing.T) {
+	testCases := []struct {
+		err       HttpError
+		retryable bool
+		timeout   bool
+	}{
+		{err: NewBadRequestError(""), retryable: false},
+		{err: NewRequestTimeoutError(""), retryable: true, timeout: true},
+		{err: NewTooManyRequestsError(""), retryable: true},
+		{err: NewInternalServerError("", nil), retryable: false},
+		{err: NewBadGatewayError("", nil), retryable: true},
+		{err: NewServiceUnavailableError("", nil), retryable: true},
+		{err: NewGatewayTimeoutError(""), retryable: true, timeout: true},
+		{err: NewConflictError("").(RetryableSetter).WithRetryable(true), retryable: true},
+		{err: NewServiceUnavailableError("", nil).(RetryableSetter).WithRetryable(false), retryable: false},
+		{err: NewInternalServerError("", nil).(RetryableSetter).WithTimeout(true), timeout: true},
+		{err: NewGatewayTimeoutError("").(RetryableSetter).WithTimeout(false), retryable: true},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%d", i, tc.err.StatusCode()), func(t *testing.T) {
+			require.Equal(t, tc.retryable, tc.err.(RetryableSetter).Retryable())
+			require.Equal(t, tc.retryable, tc.err.(RetryableSetter).Temporary())
+			require.Equal(t, tc.timeout, tc.err.(RetryableSetter).Timeout())
+			require.Equal(t, tc.retryable, IsRetryable(fmt.Errorf("wrapped: %w", tc.err)))
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	require.False(t, IsRetryable(nil))
+	require.False(t, IsRetryable(errors.New("plain")))
+	require.True(t, IsRetryable(fmt.Errorf("wrapped: %w", &testStatusError{"", http.StatusServiceUnavailable})))
+	require.False(t, IsRetryable(&testStatusError{"", http.StatusTeapot}))
+	require.True(t, IsRetryable(fmt.Errorf("wrapped: %w", ServiceUnavailable)))
+}
+
+func TestDefaultErrorWriter_Retryable(t *testing.T) {
+	testCases := []struct {
+		err    HttpError
+		expect any
+	}{
+		{err: NewBadRequestError("whoops"), expect: nil},
+		{err: NewBadGatewayError("whoops", nil), expect: true},
+		{err: NewBadGatewayError("whoops", nil).(RetryableSetter).WithRetryable(false), expect: false},
+		{err: NewConflictError("whoops").(RetryableSetter).WithRetryable(true), expect: true},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(tc.err, w)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, body["$retryable"])
+	}
+}
@@ -64,6 +64,9 @@ var DefaultPackageName string
 // MaxStackDepth is the maximum stack depth to capture
 var MaxStackDepth uint = 16
 
+// MaxCauseDepth is the maximum depth of causes captured when describing the cause chain (or tree) of an error
+var MaxCauseDepth uint = 32
+
 // DefaultFrameFormatter is the formatter used to format call stack frames when formatting StackError
 //
 // If this is set to nil, no stack info is output when formatting StackError
@@ -0,0 +1,127 @@
+package httperr
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// StatusOf returns the status code for the error
+//
+// the error chain is searched for a StatusError (including HttpError) - if there is none, the status
+// is determined by the DefaultErrorStatusResolver (if set) or is otherwise 500 Internal Server Error
+//
+// returns 0 for a nil error
+func StatusOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var se StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode()
+	}
+	if DefaultErrorStatusResolver != nil {
+		return DefaultErrorStatusResolver.Resolve(err, http.StatusInternalServerError)
+	}
+	return http.StatusInternalServerError
+}
+
+// IsStatus determines whether the status code of the error (see StatusOf) is any of the supplied status codes
+func IsStatus(err error, statuses ...int) bool {
+	return err != nil && slices.Contains(statuses, StatusOf(err))
+}
+
+// IsRedirect determines whether the status code of the error (see StatusOf) is a 3xx status
+func IsRedirect(err error) bool {
+	return statusClass(err) == 3
+}
+
+// IsClientError determines whether the status code of the error (see StatusOf) is a 4xx status
+func IsClientError(err error) bool {
+	return statusClass(err) == 4
+}
+
+// IsServerError determines whether the status code of the error (see StatusOf) is a 5xx status
+func IsServerError(err error) bool {
+	return statusClass(err) == 5
+}
+
+func statusClass(err error) int {
+	return StatusOf(err) / 100
+}
+
+// Sentinel is a status code sentinel error - for use with errors.Is
+//
+// errors created by this package match the sentinel for their status code, e.g.
+//
+//	errors.Is(err, httperr.NotFound)
+//
+// a Sentinel is also a StatusError (with the message derived from http.StatusText)
+type Sentinel int
+
+var _ StatusError = Sentinel(0)
+
+func (s Sentinel) Error() string {
+	return http.StatusText(int(s))
+}
+
+func (s Sentinel) StatusCode() int {
+	return int(s)
+}
+
+func (e *httpError) Is(target error) bool {
+	if s, ok := target.(Sentinel); ok {
+		return int(s) == e.status
+	}
+	return false
+}
+
+// status code sentinels (see Sentinel)
+var (
+	MultipleChoices              = Sentinel(http.StatusMultipleChoices)
+	MovedPermanently             = Sentinel(http.StatusMovedPermanently)
+	Found                        = Sentinel(http.StatusFound)
+	SeeOther                     = Sentinel(http.StatusSeeOther)
+	NotModified                  = Sentinel(http.StatusNotModified)
+	TemporaryRedirect            = Sentinel(http.StatusTemporaryRedirect)
+	PermanentRedirect            = Sentinel(http.StatusPermanentRedirect)
+	BadRequest                   = Sentinel(http.StatusBadRequest)
+	Unauthorized                 = Sentinel(http.StatusUnauthorized)
+	PaymentRequired              = Sentinel(http.StatusPaymentRequired)
+	Forbidden                    = Sentinel(http.StatusForbidden)
+	NotFound                     = Sentinel(http.StatusNotFound)
+	MethodNotAllowed             = Sentinel(http.StatusMethodNotAllowed)
+	NotAcceptable                = Sentinel(http.StatusNotAcceptable)
+	ProxyAuthRequired            = Sentinel(http.StatusProxyAuthRequired)
+	RequestTimeout               = Sentinel(http.StatusRequestTimeout)
+	Conflict                     = Sentinel(http.StatusConflict)
+	Gone                         = Sentinel(http.StatusGone)
+	LengthRequired               = Sentinel(http.StatusLengthRequired)
+	PreconditionFailed           = Sentinel(http.StatusPreconditionFailed)
+	RequestEntityTooLarge        = Sentinel(http.StatusRequestEntityTooLarge)
+	RequestURITooLong            = Sentinel(http.StatusRequestURITooLong)
+	UnsupportedMediaType         = Sentinel(http.StatusUnsupportedMediaType)
+	RequestedRangeNotSatisfiable = Sentinel(http.StatusRequestedRangeNotSatisfiable)
+	ExpectationFailed            = Sentinel(http.StatusExpectationFailed)
+	MisdirectedRequest           = Sentinel(http.StatusMisdirectedRequest)
+	UnprocessableEntity          = Sentinel(http.StatusUnprocessableEntity)
+	Locked                       = Sentinel(http.StatusLocked)
+	FailedDependency             = Sentinel(http.StatusFailedDependency)
+	TooEarly                     = Sentinel(http.StatusTooEarly)
+	UpgradeRequired              = Sentinel(http.StatusUpgradeRequired)
+	PreconditionRequired         = Sentinel(http.StatusPreconditionRequired)
+	TooManyRequests              = Sentinel(http.StatusTooManyRequests)
+	RequestHeaderFieldsTooLarge  = Sentinel(http.StatusRequestHeaderFieldsTooLarge)
+	UnavailableForLegalReasons   = Sentinel(http.StatusUnavailableForLegalReasons)
+	InternalServerError          = Sentinel(http.StatusInternalServerError)
+	NotImplemented               = Sentinel(http.StatusNotImplemented)
+	BadGateway                   = Sentinel(http.StatusBadGateway)
+	ServiceUnavailable           = Sentinel(http.StatusServiceUnavailable)
+	GatewayTimeout               = Sentinel(http.StatusGatewayTimeout)
+	HTTPVersionNotSupported      = Sentinel(http.StatusHTTPVersionNotSupported)
+	VariantAlsoNegotiates        = Sentinel(http.StatusVariantAlsoNegotiates)
+	InsufficientStorage          = Sentinel(http.StatusInsufficientStorage)
+	LoopDetected                 = Sentinel(http.StatusLoopDetected)
+	NotExtended                  = Sentinel(http.StatusNotExtended)
+	NetworkAuthRequired          = Sentinel(http.StatusNetworkAuthenticationRequired)
+)
@@ -0,0 +1,58 @@
+package httperr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestStatusOf(t *testing.T) {
+	require.Equal(t, 0, StatusOf(nil))
+	require.Equal(t, http.StatusNotFound, StatusOf(NewNotFoundError("")))
+	require.Equal(t, http.StatusNotFound, StatusOf(fmt.Errorf("wrapped: %w", NewNotFoundError(""))))
+	require.Equal(t, http.StatusTeapot, StatusOf(fmt.Errorf("wrapped: %w", &testStatusError{"", http.StatusTeapot})))
+	require.Equal(t, http.StatusInternalServerError, StatusOf(sql.ErrNoRows))
+	DefaultErrorStatusResolver = &testErrorStatusResolver{}
+	defer func() { DefaultErrorStatusResolver = nil }()
+	require.Equal(t, http.StatusNotFound, StatusOf(sql.ErrNoRows))
+}
+
+func TestIsStatus(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", NewConflictError(""))
+	require.True(t, IsStatus(err, http.StatusNotFound, http.StatusConflict))
+	require.False(t, IsStatus(err, http.StatusNotFound))
+	require.False(t, IsStatus(err))
+	require.False(t, IsStatus(nil, 0))
+}
+
+func TestStatusClassPredicates(t *testing.T) {
+	redirect := fmt.Errorf("wrapped: %w", NewSeeOtherError("", "/"))
+	client := fmt.Errorf("wrapped: %w", NewBadRequestError(""))
+	server := fmt.Errorf("wrapped: %w", NewBadGatewayError("", nil))
+	require.True(t, IsRedirect(redirect))
+	require.False(t, IsRedirect(client))
+	require.True(t, IsClientError(client))
+	require.False(t, IsClientError(server))
+	require.True(t, IsServerError(server))
+	require.True(t, IsServerError(errors.New("plain")))
+	require.False(t, IsServerError(nil))
+	require.False(t, IsClientError(nil))
+}
+
+func TestSentinel(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", NewNotFoundError("no such thing"))
+	require.ErrorIs(t, err, NotFound)
+	require.NotErrorIs(t, err, Gone)
+	require.NotErrorIs(t, errors.New("plain"), NotFound)
+	require.ErrorIs(t, NotFound, NotFound)
+	require.ErrorIs(t, NewUnprocessableEntityError(""), UnprocessableEntity)
+	require.ErrorIs(t, NewNetworkAuthRequiredError(""), NetworkAuthRequired)
+	require.NotErrorIs(t, NewNotFoundError(""), errors.New("Not Found"))
+
+	require.Equal(t, "Not Found", NotFound.Error())
+	require.Equal(t, http.StatusNotFound, NotFound.StatusCode())
+	require.Equal(t, http.StatusNotFound, StatusOf(fmt.Errorf("wrapped: %w", NotFound)))
+}
@@ -0,0 +1,401 @@
+package httperr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ValidateTag is the struct tag name used by ValidateStruct
+//
+// the tag value is a comma separated list of rules:
+//
+//	required    - the field must be present (not nil, an empty string or an empty slice/map)
+//	min=n       - minimum value (numbers) or minimum length (strings, slices and maps)
+//	max=n       - maximum value (numbers) or maximum length (strings, slices and maps)
+//	oneof=a b   - the field must be one of the space separated values
+//	pattern=re  - the field (string) must match the regular expression (the expression may contain commas - it ends at
+//	              the next comma that is followed by another rule, e.g. ",oneof=")
+//
+// oneof and pattern do not check empty strings - use required to check for presence
+//
+// example:
+//
+//	type Request struct {
+//		Name  string   `json:"name" httperr:"required,max=64,pattern=^[a-z]+$"`
+//		Age   int      `json:"age" httperr:"min=0,max=150"`
+//		Kind  string   `json:"kind" httperr:"oneof=a b c"`
+//		Items []Item   `json:"items" httperr:"required"`
+//	}
+const ValidateTag = "httperr"
+
+// ErrInvalidValidateTag is the cause of the error returned by ValidateStruct when a struct has an invalid ValidateTag
+var ErrInvalidValidateTag = errors.New("invalid validate tag")
+
+// ValidateStruct validates a struct (or pointer to struct) using the ValidateTag on its fields
+//
+// nested structs, slices and maps (of structs) are also validated - field names are taken from `json` tags
+//
+// returns nil if the struct is valid, a 422 Unprocessable Entity error with a reason for each failure, or
+// a 500 Internal Server Error if the struct has invalid tags
+func ValidateStruct(value any) HttpError {
+	if v := Validate().Struct("", value); !v.Valid() {
+		return v.newError(getStackInfo())
+	}
+	return nil
+}
+
+// Struct validates a struct (or pointer to struct) value, using the ValidateTag on its fields, at the supplied field
+//
+// if the struct has invalid tags, Err returns a 500 Internal Server Error
+func (v *Validator) Struct(field string, value any) *Validator {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		v.validation.setErr(fmt.Errorf("%w: cannot validate %T (not a struct)", ErrInvalidValidateTag, value))
+		return v
+	}
+	v.At(field).walkStruct(rv)
+	return v
+}
+
+func (vn *validation) setErr(err error) {
+	if vn.err == nil {
+		vn.err = err
+	}
+}
+
+type structPlan struct {
+	fields []fieldPlan
+	err    error
+}
+
+type fieldPlan struct {
+	index    int
+	name     string
+	embedded bool
+	rules    []fieldRule
+	walk     walkKind
+}
+
+type fieldRule func(v *Validator, field string, value any)
+
+type walkKind int
+
+const (
+	walkNone walkKind = iota
+	walkStruct
+	walkSlice
+	walkMap
+)
+
+var structPlans sync.Map
+
+func planFor(t reflect.Type) *structPlan {
+	if p, ok := structPlans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	p, _ := structPlans.LoadOrStore(t, buildPlan(t))
+	return p.(*structPlan)
+}
+
+func buildPlan(t reflect.Type) *structPlan {
+	plan := &structPlan{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !(sf.Anonymous && derefType(sf.Type).Kind() == reflect.Struct) {
+			continue
+		}
+		tag, hasTag := sf.Tag.Lookup(ValidateTag)
+		if tag == "-" {
+			continue
+		}
+		name, named := jsonName(sf)
+		fp := fieldPlan{
+			index:    i,
+			name:     name,
+			embedded: sf.Anonymous && !named,
+			walk:     walkKindOf(sf.Type),
+		}
+		if hasTag {
+			rules, err := parseRules(sf.Type, tag)
+			if err != nil {
+				plan.err = fmt.Errorf("%w: %s.%s: %w", ErrInvalidValidateTag, t.String(), sf.Name, err)
+				return plan
+			}
+			fp.rules = rules
+		}
+		if len(fp.rules) > 0 || fp.walk != walkNone {
+			plan.fields = append(plan.fields, fp)
+		}
+	}
+	return plan
+}
+
+func jsonName(sf reflect.StructField) (string, bool) {
+	if tag, ok := sf.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name, true
+		}
+	}
+	return sf.Name, false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func walkKindOf(t reflect.Type) walkKind {
+	t = derefType(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		return walkStruct
+	case reflect.Slice, reflect.Array:
+		if derefType(t.Elem()).Kind() == reflect.Struct {
+			return walkSlice
+		}
+	case reflect.Map:
+		if derefType(t.Elem()).Kind() == reflect.Struct {
+			return walkMap
+		}
+	}
+	return walkNone
+}
+
+func parseRules(t reflect.Type, tag string) (rules []fieldRule, err error) {
+	kind := derefType(t).Kind()
+	var min, max *float64
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "pattern=") {
+			rule, tag = cutPattern(tag)
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			rules = append(rules, func(v *Validator, field string, value any) {
+				v.Required(field, value)
+			})
+		case "min", "max":
+			f, pErr := strconv.ParseFloat(arg, 64)
+			if pErr != nil {
+				return nil, fmt.Errorf("%s=%q: %w", name, arg, pErr)
+			}
+			if name == "min" {
+				min = &f
+			} else {
+				max = &f
+			}
+		case "pattern":
+			if kind != reflect.String {
+				return nil, fmt.Errorf("pattern on non-string kind %s", kind)
+			}
+			re, cErr := regexp.Compile(arg)
+			if cErr != nil {
+				return nil, fmt.Errorf("pattern=%q: %w", arg, cErr)
+			}
+			rules = append(rules, func(v *Validator, field string, value any) {
+				if !isEmpty(value) {
+					v.Pattern(field, value, re)
+				}
+			})
+		case "oneof":
+			allowed, oErr := parseOneOf(derefType(t), arg)
+			if oErr != nil {
+				return nil, oErr
+			}
+			rules = append(rules, func(v *Validator, field string, value any) {
+				if !isEmpty(value) {
+					v.OneOf(field, value, allowed...)
+				}
+			})
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	if min != nil || max != nil {
+		rule, rErr := boundsRule(kind, min, max)
+		if rErr != nil {
+			return nil, rErr
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// cutPattern cuts the pattern rule from the tag - the pattern ends at the next comma followed by a known rule
+func cutPattern(tag string) (string, string) {
+	for i := strings.IndexByte(tag, ','); i >= 0; {
+		if startsWithRule(tag[i+1:]) {
+			return tag[:i], tag[i+1:]
+		}
+		next := strings.IndexByte(tag[i+1:], ',')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return tag, ""
+}
+
+func startsWithRule(s string) bool {
+	s = strings.TrimLeft(s, " ")
+	if rest, ok := strings.CutPrefix(s, "required"); ok {
+		return rest == "" || rest[0] == ','
+	}
+	for _, prefix := range []string{"min=", "max=", "oneof=", "pattern="} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func boundsRule(kind reflect.Kind, min, max *float64) (fieldRule, error) {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		switch {
+		case min == nil:
+			return func(v *Validator, field string, value any) { v.Max(field, value, *max) }, nil
+		case max == nil:
+			return func(v *Validator, field string, value any) { v.Min(field, value, *min) }, nil
+		}
+		return func(v *Validator, field string, value any) { v.Range(field, value, *min, *max) }, nil
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		lmin, lmax := 0, -1
+		if min != nil {
+			lmin = int(*min)
+		}
+		if max != nil {
+			lmax = int(*max)
+		}
+		return func(v *Validator, field string, value any) { v.Length(field, value, lmin, lmax) }, nil
+	}
+	return nil, fmt.Errorf("min/max on unsupported kind %s", kind)
+}
+
+func parseOneOf(t reflect.Type, arg string) ([]any, error) {
+	strs := strings.Fields(arg)
+	if len(strs) == 0 {
+		return nil, errors.New("oneof with no values")
+	}
+	result := make([]any, len(strs))
+	for i, s := range strs {
+		var parsed any
+		var err error
+		switch t.Kind() {
+		case reflect.String:
+			parsed = s
+		case reflect.Bool:
+			parsed, err = strconv.ParseBool(s)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			parsed, err = strconv.ParseInt(s, 10, t.Bits())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			parsed, err = strconv.ParseUint(s, 10, t.Bits())
+		case reflect.Float32, reflect.Float64:
+			parsed, err = strconv.ParseFloat(s, t.Bits())
+		default:
+			return nil, fmt.Errorf("oneof on unsupported kind %s", t.Kind())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("oneof value %q: %w", s, err)
+		}
+		result[i] = reflect.ValueOf(parsed).Convert(t).Interface()
+	}
+	return result, nil
+}
+
+func (v *Validator) walkStruct(rv reflect.Value) {
+	plan := planFor(rv.Type())
+	if plan.err != nil {
+		v.validation.setErr(plan.err)
+		return
+	}
+	for _, fp := range plan.fields {
+		fv := rv.Field(fp.index)
+		if fp.embedded {
+			if ev, ok := derefValue(fv); ok && ev.Kind() == reflect.Struct {
+				v.walkStruct(ev)
+			}
+			continue
+		}
+		if len(fp.rules) > 0 {
+			value := fv.Interface()
+			for _, rule := range fp.rules {
+				rule(v, fp.name, value)
+			}
+		}
+		v.walkValue(fp.name, fp.walk, fv)
+	}
+}
+
+func (v *Validator) walkValue(field string, walk walkKind, fv reflect.Value) *Validator {
+	if walk == walkNone {
+		return v
+	}
+	fv, ok := derefValue(fv)
+	if !ok {
+		return v
+	}
+	switch walk {
+	case walkStruct:
+		v.At(field).walkStruct(fv)
+	case walkSlice:
+		for i := 0; i < fv.Len(); i++ {
+			if ev, ok := derefValue(fv.Index(i)); ok {
+				v.Index(field, i).walkStruct(ev)
+			}
+		}
+	case walkMap:
+		child := v.At(field)
+		keys := fv.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = fmt.Sprint(k.Interface())
+		}
+		sort.Sort(mapKeys{names, keys})
+		for i, k := range keys {
+			if ev, ok := derefValue(fv.MapIndex(k)); ok {
+				child.At(names[i]).walkStruct(ev)
+			}
+		}
+	}
+	return v
+}
+
+func derefValue(rv reflect.Value) (reflect.Value, bool) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return rv, false
+		}
+		rv = rv.Elem()
+	}
+	return rv, true
+}
+
+type mapKeys struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (m mapKeys) Len() int           { return len(m.names) }
+func (m mapKeys) Less(i, j int) bool { return m.names[i] < m.names[j] }
+func (m mapKeys) Swap(i, j int) {
+	m.names[i], m.names[j] = m.names[j], m.names[i]
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+}
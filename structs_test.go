@@ -0,0 +1,240 @@
+package httperr
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	Street string `json:"street" httperr:"required"`
+	Zip    string `json:"zip,omitempty" httperr:"pattern=^[0-9]{3,5}$"`
+}
+
+type testBase struct {
+	Id string `json:"id" httperr:"required"`
+}
+
+type testItem struct {
+	Sku string `json:"sku" httperr:"required,max=8"`
+	Qty uint   `json:"qty" httperr:"min=1"`
+}
+
+type testRequest struct {
+	testBase
+	Name     string                  `json:"name" httperr:"required,min=2,max=5"`
+	Age      *int                    `json:"age" httperr:"min=0,max=150"`
+	Score    float64                 `json:"score" httperr:"max=10"`
+	Kind     string                  `json:"kind" httperr:"oneof=a b"`
+	Level    int8                    `json:"level" httperr:"oneof=1 2 3"`
+	Address  *testAddress            `json:"address" httperr:"required"`
+	Items    []testItem              `json:"items" httperr:"required"`
+	ItemPtrs []*testItem             `json:"itemPtrs"`
+	ByName   map[string]*testAddress `json:"byName"`
+	Tags     []string                `json:"tags" httperr:"max=2"`
+	NoJson   string                  `httperr:"required"`
+	Ignored  string                  `json:"ignored" httperr:"-"`
+	internal string
+}
+
+func validTestRequest() *testRequest {
+	age := 30
+	return &testRequest{
+		testBase: testBase{Id: "x"},
+		Name:     "bob",
+		Age:      &age,
+		Kind:     "a",
+		Level:    2,
+		Address:  &testAddress{Street: "main"},
+		Items:    []testItem{{Sku: "a", Qty: 1}},
+		NoJson:   "x",
+	}
+}
+
+func TestValidateStruct_Valid(t *testing.T) {
+	require.Nil(t, ValidateStruct(validTestRequest()))
+	require.Nil(t, ValidateStruct(*validTestRequest()))
+}
+
+func TestValidateStruct_Failures(t *testing.T) {
+	age := 200
+	req := &testRequest{
+		Name:     "b",
+		Age:      &age,
+		Score:    10.5,
+		Kind:     "c",
+		Level:    4,
+		Items:    []testItem{{Sku: "a", Qty: 1}, {Sku: "123456789"}},
+		ItemPtrs: []*testItem{nil, {Sku: "x"}},
+		ByName:   map[string]*testAddress{"b": {Zip: "x"}, "a": {Street: "s", Zip: "12"}},
+		Tags:     []string{"a", "b", "c"},
+	}
+	err := ValidateStruct(req)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, err.StatusCode())
+	require.Contains(t, err.StackInfo()[0].Function, "TestValidateStruct_Failures")
+	fields := make([]string, 0)
+	for _, r := range err.Reasons() {
+		reason := r.(Reason)
+		fields = append(fields, reason.Field+" "+reason.Code)
+	}
+	require.Equal(t, []string{
+		"id required",
+		"name length",
+		"age range",
+		"score range",
+		"kind oneOf",
+		"level oneOf",
+		"address required",
+		"items[1].sku length",
+		"items[1].qty range",
+		"itemPtrs[1].qty range",
+		"byName.a.zip pattern",
+		"byName.b.street required",
+		"byName.b.zip pattern",
+		"tags length",
+		"NoJson required",
+	}, fields)
+	reasons := err.Reasons()
+	require.Equal(t, "/items/1/sku", reasons[7].(Reason).Pointer)
+	require.Equal(t, "must be at most 10", reasons[3].(Reason).Message)
+	require.Equal(t, "must be at least 1", reasons[8].(Reason).Message)
+	require.Equal(t, "must be one of 1, 2, 3", reasons[5].(Reason).Message)
+	require.Equal(t, "length must be between 0 and 2", reasons[13].(Reason).Message)
+}
+
+func TestValidateStruct_InvalidTags(t *testing.T) {
+	testCases := []any{
+		struct {
+			A int `httperr:"min=x"`
+		}{},
+		struct {
+			A int `httperr:"pattern=^a$"`
+		}{},
+		struct {
+			A string `httperr:"pattern=("`
+		}{},
+		struct {
+			A bool `httperr:"min=1"`
+		}{},
+		struct {
+			A int `httperr:"oneof=a"`
+		}{},
+		struct {
+			A []string `httperr:"oneof=a"`
+		}{},
+		struct {
+			A string `httperr:"oneof="`
+		}{},
+		struct {
+			A string `httperr:"unknown"`
+		}{},
+		struct {
+			A struct {
+				B string `httperr:"bad"`
+			}
+		}{},
+		"not a struct",
+		(*testRequest)(nil),
+	}
+	for _, tc := range testCases {
+		err := ValidateStruct(tc)
+		require.Error(t, err)
+		require.Equal(t, http.StatusInternalServerError, err.StatusCode())
+		require.True(t, errors.Is(err, ErrInvalidValidateTag))
+	}
+}
+
+func TestValidateStruct_Pattern(t *testing.T) {
+	type patterned struct {
+		A string `httperr:"required,pattern=^[a-z]+,[0-9]+$"`
+		B bool   `httperr:"oneof=true"`
+	}
+	require.Nil(t, ValidateStruct(patterned{A: "abc,123", B: true}))
+	err := ValidateStruct(patterned{A: "abc"})
+	require.Error(t, err)
+	require.Len(t, err.Reasons(), 2)
+	require.Equal(t, "must match pattern ^[a-z]+,[0-9]+$", err.Reasons()[0].(Reason).Message)
+}
+
+func TestValidateStruct_PatternFollowedByRules(t *testing.T) {
+	type tagged struct {
+		Kind string `json:"kind" httperr:"required,min=1,max=64,pattern=^[a-z]+$,oneof=a b"`
+	}
+	require.Nil(t, ValidateStruct(tagged{Kind: "a"}))
+	require.Nil(t, ValidateStruct(tagged{Kind: "b"}))
+	err := ValidateStruct(tagged{Kind: "c"})
+	require.Error(t, err)
+	require.Len(t, err.Reasons(), 1)
+	require.Equal(t, CodeOneOf, err.Reasons()[0].(Reason).Code)
+	err = ValidateStruct(tagged{Kind: "A"})
+	require.Error(t, err)
+	require.Len(t, err.Reasons(), 2)
+	require.Equal(t, "must match pattern ^[a-z]+$", err.Reasons()[0].(Reason).Message)
+	require.Equal(t, CodeOneOf, err.Reasons()[1].(Reason).Code)
+	err = ValidateStruct(tagged{})
+	require.Error(t, err)
+	require.Len(t, err.Reasons(), 2)
+	require.Equal(t, CodeRequired, err.Reasons()[0].(Reason).Code)
+	require.Equal(t, CodeLength, err.Reasons()[1].(Reason).Code)
+}
+
+func TestCutPattern(t *testing.T) {
+	testCases := []struct {
+		tag     string
+		pattern string
+		rest    string
+	}{
+		{tag: "pattern=^a$", pattern: "pattern=^a$"},
+		{tag: "pattern=^a{1,2}$", pattern: "pattern=^a{1,2}$"},
+		{tag: "pattern=^a$,required", pattern: "pattern=^a$", rest: "required"},
+		{tag: "pattern=^a$,requiredx", pattern: "pattern=^a$,requiredx"},
+		{tag: "pattern=^a,b$, min=1,max=2", pattern: "pattern=^a,b$", rest: " min=1,max=2"},
+		{tag: "pattern=^a$,oneof=a", pattern: "pattern=^a$", rest: "oneof=a"},
+		{tag: "pattern=^a$,pattern=^b", pattern: "pattern=^a$", rest: "pattern=^b"},
+		{tag: "pattern=^a$,", pattern: "pattern=^a$,"},
+	}
+	for i, tc := range testCases {
+		pattern, rest := cutPattern(tc.tag)
+		require.Equal(t, tc.pattern, pattern, i)
+		require.Equal(t, tc.rest, rest, i)
+	}
+}
+
+func TestValidator_Struct(t *testing.T) {
+	v := Validate().WithStatus(http.StatusBadRequest)
+	v.Required("other", "")
+	v.Struct("body", &testAddress{})
+	err := v.Err()
+	require.Equal(t, http.StatusBadRequest, err.StatusCode())
+	require.Len(t, err.Reasons(), 2)
+	require.Equal(t, "/body/street", err.Reasons()[1].(Reason).Pointer)
+
+	v = Validate().Struct("", struct {
+		A string `httperr:"bad"`
+	}{})
+	require.False(t, v.Valid())
+	err = v.Err()
+	require.Equal(t, http.StatusInternalServerError, err.StatusCode())
+}
+
+func TestPlanFor_Cached(t *testing.T) {
+	p1 := planFor(typeOf[testItem]())
+	p2 := planFor(typeOf[testItem]())
+	require.Same(t, p1, p2)
+	require.Len(t, p1.fields, 2)
+}
+
+func BenchmarkValidateStruct(b *testing.B) {
+	req := validTestRequest()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = ValidateStruct(req)
+	}
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,381 @@
+package httperr
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator accumulates validation failures (as Reason) and produces a HttpError with all of them
+//
+// example:
+//
+//	v := httperr.Validate()
+//	v.Required("name", req.Name)
+//	v.Range("age", req.Age, 0, 150)
+//	for i, item := range req.Items {
+//		v.Index("items", i).Required("sku", item.Sku)
+//	}
+//	return v.Err()
+//
+// by default, the error produced is a 422 Unprocessable Entity (see WithStatus)
+type Validator struct {
+	field      string
+	pointer    string
+	in         string
+	validation *validation
+}
+
+type validation struct {
+	status  int
+	message string
+	reasons []Reason
+	err     error
+}
+
+// Rule is a custom validation rule (see Validator.Rule)
+//
+// a rule returns a non-nil error when the value is invalid - the error message is used as the reason message
+type Rule func(value any) error
+
+// reason codes used by Validator
+const (
+	CodeRequired = "required"
+	CodeRange    = "range"
+	CodeLength   = "length"
+	CodePattern  = "pattern"
+	CodeOneOf    = "oneOf"
+	CodeInvalid  = "invalid"
+)
+
+// Validate creates a new Validator
+func Validate() *Validator {
+	return &Validator{
+		validation: &validation{
+			status: http.StatusUnprocessableEntity,
+		},
+	}
+}
+
+// WithStatus sets the status code of the error produced (e.g. http.StatusBadRequest)
+func (v *Validator) WithStatus(status int) *Validator {
+	v.validation.status = status
+	return v
+}
+
+// WithMessage sets the message of the error produced
+//
+// if not set, the message is derived from http.StatusText for the status code
+func (v *Validator) WithMessage(msg string) *Validator {
+	v.validation.message = msg
+	return v
+}
+
+// At returns a Validator for a nested field (e.g. v.At("address").Required("street", ...) reports "address.street")
+//
+// the returned Validator shares its failures with the parent Validator
+func (v *Validator) At(field string) *Validator {
+	return &Validator{
+		field:      v.fieldPath(field),
+		pointer:    v.pointerPath(field),
+		in:         v.in,
+		validation: v.validation,
+	}
+}
+
+// In returns a Validator for request parameters in the location (InQuery, InPath, InHeader or InForm) - reasons added
+// have the Meta "in" set to the location (and no Pointer, as the parameters are not part of a json body)
+//
+// the returned Validator shares its failures with the parent Validator
+func (v *Validator) In(location string) *Validator {
+	return &Validator{
+		field:      v.field,
+		in:         location,
+		validation: v.validation,
+	}
+}
+
+// Index returns a Validator for an element of a slice field (e.g. v.Index("items", 2).Required("sku", ...) reports "items[2].sku")
+//
+// the returned Validator shares its failures with the parent Validator
+func (v *Validator) Index(field string, index int) *Validator {
+	i := strconv.Itoa(index)
+	return &Validator{
+		field:      v.fieldPath(field) + "[" + i + "]",
+		pointer:    v.pointerPath(field) + "/" + i,
+		in:         v.in,
+		validation: v.validation,
+	}
+}
+
+// Add adds a failure reason - the reason field and pointer are set from the supplied field (relative to this Validator)
+func (v *Validator) Add(field string, reason Reason) *Validator {
+	reason.Field = v.fieldPath(field)
+	if v.in != "" {
+		reason = reason.WithMeta("in", v.in)
+	} else {
+		reason.Pointer = v.pointerPath(field)
+	}
+	v.validation.reasons = append(v.validation.reasons, reason)
+	return v
+}
+
+// Required checks that the value is present - i.e. not nil, an empty string or an empty slice/map
+func (v *Validator) Required(field string, value any) *Validator {
+	if isEmpty(value) {
+		v.Add(field, NewReason("is required").WithCode(CodeRequired))
+	}
+	return v
+}
+
+// Range checks that a numeric value is between min and max (inclusive)
+//
+// nil values (or nil pointers) are not checked - use Required to check for presence
+func (v *Validator) Range(field string, value any, min float64, max float64) *Validator {
+	if isNil(value) {
+		return v
+	}
+	if n, ok := asNumber(value); !ok {
+		v.Add(field, NewReason("must be a number").WithCode(CodeInvalid).WithValue(numberValue(value)))
+	} else if n < min || n > max {
+		v.Add(field, NewReason(fmt.Sprintf("must be between %v and %v", min, max)).WithCode(CodeRange).WithValue(numberValue(value)).
+			WithMeta("min", min).WithMeta("max", max))
+	}
+	return v
+}
+
+// Min checks that a numeric value is at least min
+//
+// nil values (or nil pointers) are not checked - use Required to check for presence
+func (v *Validator) Min(field string, value any, min float64) *Validator {
+	if isNil(value) {
+		return v
+	}
+	if n, ok := asNumber(value); !ok {
+		v.Add(field, NewReason("must be a number").WithCode(CodeInvalid).WithValue(numberValue(value)))
+	} else if n < min {
+		v.Add(field, NewReason(fmt.Sprintf("must be at least %v", min)).WithCode(CodeRange).WithValue(numberValue(value)).
+			WithMeta("min", min))
+	}
+	return v
+}
+
+// Max checks that a numeric value is at most max
+//
+// nil values (or nil pointers) are not checked - use Required to check for presence
+func (v *Validator) Max(field string, value any, max float64) *Validator {
+	if isNil(value) {
+		return v
+	}
+	if n, ok := asNumber(value); !ok {
+		v.Add(field, NewReason("must be a number").WithCode(CodeInvalid).WithValue(numberValue(value)))
+	} else if n > max {
+		v.Add(field, NewReason(fmt.Sprintf("must be at most %v", max)).WithCode(CodeRange).WithValue(numberValue(value)).
+			WithMeta("max", max))
+	}
+	return v
+}
+
+// Length checks that the length of a string (in characters), slice or map is between min and max (inclusive)
+//
+// a max less than zero means no maximum - nil values (or nil pointers) are not checked
+func (v *Validator) Length(field string, value any, min int, max int) *Validator {
+	if isNil(value) {
+		return v
+	}
+	if l, ok := lengthOf(value); !ok {
+		v.Add(field, NewReason("must be a string, array or object").WithCode(CodeInvalid))
+	} else if l < min || (max >= 0 && l > max) {
+		reason := NewReason(fmt.Sprintf("length must be at least %d", min)).WithCode(CodeLength).WithMeta("min", min)
+		if max >= 0 {
+			reason = NewReason(fmt.Sprintf("length must be between %d and %d", min, max)).WithCode(CodeLength).
+				WithMeta("min", min).WithMeta("max", max)
+		}
+		v.Add(field, reason)
+	}
+	return v
+}
+
+// Pattern checks that a string value matches the regular expression
+//
+// nil values (or nil pointers) are not checked
+func (v *Validator) Pattern(field string, value any, pattern *regexp.Regexp) *Validator {
+	if isNil(value) {
+		return v
+	}
+	if rv := reflect.Indirect(reflect.ValueOf(value)); rv.Kind() != reflect.String {
+		v.Add(field, NewReason("must be a string").WithCode(CodeInvalid).WithValue(value))
+	} else if s := rv.String(); !pattern.MatchString(s) {
+		v.Add(field, NewReason("must match pattern "+pattern.String()).WithCode(CodePattern).WithValue(s).
+			WithMeta("pattern", pattern.String()))
+	}
+	return v
+}
+
+// OneOf checks that the value is one of the allowed values
+//
+// nil values (or nil pointers) are not checked
+func (v *Validator) OneOf(field string, value any, allowed ...any) *Validator {
+	if isNil(value) {
+		return v
+	}
+	actual := reflect.Indirect(reflect.ValueOf(value)).Interface()
+	for _, a := range allowed {
+		if valuesEqual(a, actual) {
+			return v
+		}
+	}
+	strs := make([]string, len(allowed))
+	for i, a := range allowed {
+		strs[i] = fmt.Sprint(a)
+	}
+	v.Add(field, NewReason("must be one of "+strings.Join(strs, ", ")).WithCode(CodeOneOf).WithValue(actual).
+		WithMeta("allowed", allowed))
+	return v
+}
+
+// Check adds a failure (with the supplied message) if ok is false
+func (v *Validator) Check(field string, ok bool, message string) *Validator {
+	if !ok {
+		v.Add(field, NewReason(message).WithCode(CodeInvalid))
+	}
+	return v
+}
+
+// Rule checks the value using a custom Rule
+func (v *Validator) Rule(field string, value any, rule Rule) *Validator {
+	if err := rule(value); err != nil {
+		v.Add(field, NewReason(err.Error()).WithCode(CodeInvalid).WithValue(value))
+	}
+	return v
+}
+
+// Valid returns whether there are no validation failures
+func (v *Validator) Valid() bool {
+	return len(v.validation.reasons) == 0 && v.validation.err == nil
+}
+
+// Reasons returns the validation failures
+func (v *Validator) Reasons() []Reason {
+	return v.validation.reasons
+}
+
+// Err returns a HttpError with all validation failures as reasons - or nil if there are no failures
+//
+// if validation itself failed (e.g. Struct with invalid tags), Err returns a 500 Internal Server Error
+func (v *Validator) Err() HttpError {
+	if v.Valid() {
+		return nil
+	}
+	return v.newError(getStackInfo())
+}
+
+func (v *Validator) newError(si StackInfo) HttpError {
+	if v.validation.err != nil {
+		return newError(http.StatusInternalServerError, "", v.validation.err, si)
+	}
+	reasons := make([]any, len(v.validation.reasons))
+	for i, r := range v.validation.reasons {
+		reasons[i] = r
+	}
+	return newError(v.validation.status, v.validation.message, nil, si).AddReasons(reasons...)
+}
+
+func (v *Validator) fieldPath(field string) string {
+	switch {
+	case field == "":
+		return v.field
+	case v.field == "":
+		return field
+	}
+	return v.field + "." + field
+}
+
+func (v *Validator) pointerPath(field string) string {
+	if field == "" {
+		return v.pointer
+	}
+	return v.pointer + "/" + pointerEscaper.Replace(field)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// valuesEqual compares values without panicking on uncomparable values (e.g. slices or maps)
+//
+// an allowed value of a different type is converted to the type of the value when both are of the same kind
+// (e.g. "red" and a named string type Color("red") are equal)
+func valuesEqual(allowed any, value any) bool {
+	if at, vt := reflect.TypeOf(allowed), reflect.TypeOf(value); at != vt {
+		if at == nil || vt == nil || at.Kind() != vt.Kind() || !at.ConvertibleTo(vt) {
+			return false
+		}
+		allowed = reflect.ValueOf(allowed).Convert(vt).Interface()
+	}
+	if reflect.ValueOf(allowed).Comparable() && reflect.ValueOf(value).Comparable() {
+		return allowed == value
+	}
+	return reflect.DeepEqual(allowed, value)
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func isEmpty(value any) bool {
+	if isNil(value) {
+		return true
+	}
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	}
+	return false
+}
+
+// numberValue returns the value for a number reason - NaN and infinite values are returned as strings
+// (as they cannot be encoded as json numbers)
+func numberValue(value any) any {
+	if rv := reflect.Indirect(reflect.ValueOf(value)); rv.CanFloat() && (math.IsNaN(rv.Float()) || math.IsInf(rv.Float(), 0)) {
+		return fmt.Sprint(rv.Float())
+	}
+	return value
+}
+
+func asNumber(value any) (float64, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		// NaN is not a number (and would otherwise pass every bound)
+		return rv.Float(), !math.IsNaN(rv.Float())
+	}
+	return 0, false
+}
+
+func lengthOf(value any) (int, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch rv.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(rv.String()), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,255 @@
+package httperr
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"math"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestValidate_NoFailures(t *testing.T) {
+	name := "bob"
+	v := Validate()
+	v.Required("name", name).
+		Required("ptr", &name).
+		Required("count", 0).
+		Range("age", 21, 0, 150).
+		Range("missing", (*int)(nil), 0, 1).
+		Length("name", &name, 1, 10).
+		Pattern("name", name, regexp.MustCompile(`^[a-z]+$`)).
+		OneOf("colour", "red", "red", "green").
+		Check("other", true, "not shown").
+		Rule("custom", 1, func(value any) error { return nil })
+	require.True(t, v.Valid())
+	require.Empty(t, v.Reasons())
+	require.Nil(t, v.Err())
+}
+
+func TestValidator_Required(t *testing.T) {
+	var nilPtr *string
+	empty := ""
+	v := Validate().
+		Required("a", nil).
+		Required("b", "").
+		Required("c", nilPtr).
+		Required("d", &empty).
+		Required("e", []string{}).
+		Required("f", map[string]any{}).
+		Required("g", false)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 6)
+	require.Equal(t, Reason{Code: CodeRequired, Message: "is required", Field: "a", Pointer: "/a"}, reasons[0])
+	require.Equal(t, "f", reasons[5].Field)
+}
+
+func TestValidator_Range(t *testing.T) {
+	f := 1.5
+	v := Validate().
+		Range("a", 200, 0, 150).
+		Range("b", uint8(5), 10, 20).
+		Range("c", &f, 2, 3).
+		Range("d", "x", 0, 1).
+		Range("e", 150, 0, 150)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 4)
+	require.Equal(t, Reason{Code: CodeRange, Message: "must be between 0 and 150", Field: "a", Pointer: "/a", Value: 200,
+		Meta: map[string]any{"min": float64(0), "max": float64(150)}}, reasons[0])
+	require.Equal(t, "must be between 10 and 20", reasons[1].Message)
+	require.Equal(t, "must be between 2 and 3", reasons[2].Message)
+	require.Equal(t, CodeInvalid, reasons[3].Code)
+	require.Equal(t, "must be a number", reasons[3].Message)
+}
+
+func TestValidator_Range_NonFinite(t *testing.T) {
+	nan := math.NaN()
+	v := Validate().
+		Range("a", nan, 0, 1).
+		Min("b", &nan, 0).
+		Max("c", float32(nan), 1).
+		Range("d", math.Inf(1), 0, 1).
+		Max("e", math.Inf(-1), 1)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 4)
+	for i, field := range []string{"a", "b", "c"} {
+		require.Equal(t, field, reasons[i].Field)
+		require.Equal(t, CodeInvalid, reasons[i].Code)
+		require.Equal(t, "must be a number", reasons[i].Message)
+		require.Equal(t, "NaN", reasons[i].Value)
+	}
+	require.Equal(t, CodeRange, reasons[3].Code)
+	require.Equal(t, "+Inf", reasons[3].Value)
+	_, err := json.Marshal(v.Err())
+	require.NoError(t, err)
+}
+
+func TestValidator_Length(t *testing.T) {
+	v := Validate().
+		Length("a", "héllo", 1, 4).
+		Length("b", "", 1, -1).
+		Length("c", []int{1, 2, 3}, 0, 2).
+		Length("d", 1, 0, 2).
+		Length("e", "héllo", 5, 5).
+		Length("f", nil, 1, 2)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 4)
+	require.Equal(t, "length must be between 1 and 4", reasons[0].Message)
+	require.Equal(t, map[string]any{"min": 1, "max": 4}, reasons[0].Meta)
+	require.Equal(t, "length must be at least 1", reasons[1].Message)
+	require.Equal(t, map[string]any{"min": 1}, reasons[1].Meta)
+	require.Equal(t, "c", reasons[2].Field)
+	require.Equal(t, CodeInvalid, reasons[3].Code)
+}
+
+func TestValidator_Pattern(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9]+$`)
+	v := Validate().
+		Pattern("a", "abc", re).
+		Pattern("b", 1, re).
+		Pattern("c", nil, re)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 2)
+	require.Equal(t, Reason{Code: CodePattern, Message: "must match pattern ^[0-9]+$", Field: "a", Pointer: "/a", Value: "abc",
+		Meta: map[string]any{"pattern": "^[0-9]+$"}}, reasons[0])
+	require.Equal(t, "must be a string", reasons[1].Message)
+}
+
+type testKind string
+
+func TestValidator_Pattern_NamedString(t *testing.T) {
+	re := regexp.MustCompile(`^[a-z]+$`)
+	kind := testKind("abc")
+	v := Validate().
+		Pattern("a", testKind("abc"), re).
+		Pattern("b", &kind, re).
+		Pattern("c", testKind("ABC"), re)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 1)
+	require.Equal(t, "c", reasons[0].Field)
+	require.Equal(t, CodePattern, reasons[0].Code)
+	require.Equal(t, "ABC", reasons[0].Value)
+
+	type tagged struct {
+		Kind testKind `json:"kind" httperr:"pattern=^[a-z]+$"`
+	}
+	require.Nil(t, ValidateStruct(tagged{Kind: "abc"}))
+	err := ValidateStruct(tagged{Kind: "ABC"})
+	require.Error(t, err)
+	require.Equal(t, "must match pattern ^[a-z]+$", err.Reasons()[0].(Reason).Message)
+}
+
+func TestValidator_OneOf(t *testing.T) {
+	s := "blue"
+	v := Validate().
+		OneOf("a", &s, "red", "green").
+		OneOf("b", 3, 1, 2)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 2)
+	require.Equal(t, "must be one of red, green", reasons[0].Message)
+	require.Equal(t, "blue", reasons[0].Value)
+	require.Equal(t, []any{"red", "green"}, reasons[0].Meta["allowed"])
+	require.Equal(t, "must be one of 1, 2", reasons[1].Message)
+}
+
+func TestValidator_OneOf_NamedTypes(t *testing.T) {
+	type Color string
+	type Level int
+	red := Color("red")
+	v := Validate().
+		OneOf("a", Color("red"), "red", "blue").
+		OneOf("b", &red, "red").
+		OneOf("c", Level(2), 1, 2).
+		OneOf("d", Color("green"), "red", "blue").
+		OneOf("e", Level(65), "A").
+		OneOf("f", 1, 1.0)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 3)
+	require.Equal(t, "d", reasons[0].Field)
+	require.Equal(t, "e", reasons[1].Field)
+	require.Equal(t, "f", reasons[2].Field)
+}
+
+func TestValidator_OneOf_Uncomparable(t *testing.T) {
+	type holder struct {
+		V any
+	}
+	v := Validate().
+		OneOf("a", []string{"x"}, []string{"x"}, []string{"y"}).
+		OneOf("b", map[string]int{"x": 1}, map[string]int{"x": 1}).
+		OneOf("c", holder{V: []int{1}}, holder{V: []int{1}}).
+		OneOf("d", []string{"z"}, []string{"x"}, "z").
+		OneOf("e", holder{V: []int{2}}, holder{V: []int{1}})
+	reasons := v.Reasons()
+	require.Len(t, reasons, 2)
+	require.Equal(t, "d", reasons[0].Field)
+	require.Equal(t, "e", reasons[1].Field)
+}
+
+func TestValidator_CheckAndRule(t *testing.T) {
+	v := Validate().
+		Check("a", false, "must be even").
+		Rule("b", 3, func(value any) error {
+			return errors.New("must be prime")
+		})
+	reasons := v.Reasons()
+	require.Len(t, reasons, 2)
+	require.Equal(t, Reason{Code: CodeInvalid, Message: "must be even", Field: "a", Pointer: "/a"}, reasons[0])
+	require.Equal(t, Reason{Code: CodeInvalid, Message: "must be prime", Field: "b", Pointer: "/b", Value: 3}, reasons[1])
+}
+
+func TestValidator_NestedPaths(t *testing.T) {
+	v := Validate()
+	addr := v.At("address")
+	addr.Required("street", "")
+	addr.At("geo").Range("lat", 100, -90, 90)
+	v.Index("items", 2).Required("sku", nil)
+	v.Index("items", 3).Required("", nil)
+	v.At("a/b~c").Required("", nil)
+	v.Add("", NewReason("top level"))
+	reasons := v.Reasons()
+	require.Len(t, reasons, 6)
+	require.Equal(t, "address.street", reasons[0].Field)
+	require.Equal(t, "/address/street", reasons[0].Pointer)
+	require.Equal(t, "address.geo.lat", reasons[1].Field)
+	require.Equal(t, "/address/geo/lat", reasons[1].Pointer)
+	require.Equal(t, "items[2].sku", reasons[2].Field)
+	require.Equal(t, "/items/2/sku", reasons[2].Pointer)
+	require.Equal(t, "items[3]", reasons[3].Field)
+	require.Equal(t, "/items/3", reasons[3].Pointer)
+	require.Equal(t, "a/b~c", reasons[4].Field)
+	require.Equal(t, "/a~1b~0c", reasons[4].Pointer)
+	require.Equal(t, "", reasons[5].Field)
+	require.Equal(t, "", reasons[5].Pointer)
+	require.False(t, addr.Valid())
+}
+
+func TestValidator_In(t *testing.T) {
+	v := Validate().WithStatus(http.StatusBadRequest)
+	v.In(InQuery).Range("limit", 500, 1, 100)
+	v.In(InHeader).Required("X-Request-Id", "")
+	v.Required("body", nil)
+	reasons := v.Reasons()
+	require.Len(t, reasons, 3)
+	require.Equal(t, Reason{Code: CodeRange, Message: "must be between 1 and 100", Field: "limit", Value: 500,
+		Meta: map[string]any{"in": InQuery, "min": 1.0, "max": 100.0}}, reasons[0])
+	require.Equal(t, Reason{Code: CodeRequired, Message: "is required", Field: "X-Request-Id",
+		Meta: map[string]any{"in": InHeader}}, reasons[1])
+	require.Equal(t, "/body", reasons[2].Pointer)
+	require.Nil(t, reasons[2].Meta)
+	require.Equal(t, http.StatusBadRequest, v.Err().StatusCode())
+}
+
+func TestValidator_Err(t *testing.T) {
+	err := Validate().Required("name", "").Err()
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, err.StatusCode())
+	require.Equal(t, http.StatusText(http.StatusUnprocessableEntity), err.Error())
+	require.Equal(t, []any{Reason{Code: CodeRequired, Message: "is required", Field: "name", Pointer: "/name"}}, err.Reasons())
+	require.Contains(t, err.StackInfo()[0].Function, "TestValidator_Err")
+
+	err = Validate().WithStatus(http.StatusBadRequest).WithMessage("invalid request").Required("name", "").Err()
+	require.Equal(t, http.StatusBadRequest, err.StatusCode())
+	require.Equal(t, "invalid request", err.Error())
+}
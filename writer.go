@@ -1,10 +1,9 @@
 package httperr
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
+	"strconv"
 )
 
 // DefaultErrorWriter is the default error writer used by HttpError.Write
@@ -12,70 +11,192 @@ var DefaultErrorWriter ErrorWriter = &errorWriter{}
 
 // DefaultErrorWriterShowCause determines whether the internal default error writer
 // shows the cause in the response body json
+//
+// when set, the entire cause chain (or tree) is shown as CauseEntry items - and when
+// DefaultErrorWriterShowStack is also set, each cause entry includes its stack info (if any)
 var DefaultErrorWriterShowCause = false
 
 // DefaultErrorWriterShowStack determines whether the internal default error writer
 // shows the stack trace in the response body json
 var DefaultErrorWriterShowStack = false
 
+// EncodeFailureHandler is the interface used by DefaultEncodeFailureHandler
+type EncodeFailureHandler interface {
+	// HandleEncodeFailure is called when the response body for an error cannot be encoded
+	// (e.g. a reason contains a channel, func or cyclic value)
+	HandleEncodeFailure(err error, encodeErr error)
+}
+
+// DefaultEncodeFailureHandler is notified when the internal default error writer fails to encode
+// the response body json for an error
+//
+// when encoding fails, the internal default error writer writes a fallback body containing just the error message
+var DefaultEncodeFailureHandler EncodeFailureHandler
+
 // ErrorWriter is the interface used to write errors (i.e. HttpError.Write)
+//
+// the internal default error writer locates any HttpError (or StatusError) in the error chain - so
+// errors that wrap a HttpError are written with the HttpError status, headers and reasons.  For errors
+// that have no HttpError or StatusError in the chain, the status is determined by
+// the DefaultErrorStatusResolver (if set) or is otherwise 500 Internal Server Error
 type ErrorWriter interface {
 	WriteError(e error, w http.ResponseWriter)
 }
 
+// RequestErrorWriter is an optional interface that an ErrorWriter can implement to write errors
+// with knowledge of the request (i.e. RequestWriter.WriteRequest)
+//
+// the internal default error writer implements this interface - and applies status and method aware body rules:
+//
+// * no body (or Content-Type) for 1xx, 204 No Content and 304 Not Modified
+//
+// * only headers allowed by RFC 9110 for 304 Not Modified (e.g. ETag, Cache-Control, Vary)
+//
+// * headers only (with the Content-Length of the body that would have been written) for HEAD requests
+//
+// * redirects (3xx errors with a Location) written like http.Redirect - i.e. relative locations resolved
+// against the request URL, with a small html body for GET requests (see also DefaultRedirectPolicy)
+type RequestErrorWriter interface {
+	ErrorWriter
+	WriteRequestError(e error, w http.ResponseWriter, r *http.Request)
+}
+
 type errorWriter struct{}
 
 var _ ErrorWriter = (*errorWriter)(nil)
+var _ RequestErrorWriter = (*errorWriter)(nil)
 
+// default property names (see ResponseFormat)
 const (
-	ptyError        = "$error"
-	ptyReasons      = "$reasons"
-	ptyCause        = "$cause"
-	ptyStack        = "$stack"
-	hdrContentType  = "Content-Type"
-	applicationJson = "application/json"
+	ptyError   = "$" + PropertyError
+	ptyReasons = "$" + PropertyReasons
+	ptyCause   = "$" + PropertyCause
+	ptyStack   = "$" + PropertyStack
+)
+
+const (
+	hdrContentType   = "Content-Type"
+	hdrContentLength = "Content-Length"
+	applicationJson  = "application/json"
 )
 
 func (ew *errorWriter) WriteError(err error, w http.ResponseWriter) {
-	w.Header().Set(hdrContentType, applicationJson)
-	status := http.StatusInternalServerError
-	body := map[string]any{
-		ptyError: http.StatusText(http.StatusInternalServerError),
+	ew.WriteRequestError(err, w, nil)
+}
+
+func (ew *errorWriter) WriteRequestError(err error, w http.ResponseWriter, r *http.Request) {
+	status, msg, he := describeError(err)
+	hdrs := w.Header()
+	writeHeaders(hdrs, status, errorHeaders(he))
+	if location := redirectLocation(hdrs, status, r); location != "" && r != nil {
+		writeRedirect(w, r, status, location)
+		return
 	}
-	switch et := err.(type) {
-	case HttpError:
-		status = et.StatusCode()
-		body[ptyError] = err.Error()
-		if DefaultErrorWriterShowStack {
-			if stack := et.StackInfo(); len(stack) > 0 {
-				info := make([]string, len(stack))
-				for i, f := range stack {
-					info[i] = fmt.Sprintf("%s:%d", f.Function, f.Line)
-				}
-				body[ptyStack] = info
-			}
-		}
-		if reasons := et.Reasons(); len(reasons) > 0 {
-			body[ptyReasons] = reasons
+	if !bodyAllowed(status) {
+		w.WriteHeader(status)
+		return
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	body, encErr := DefaultResponseFormat.appendBody(*buf, status, msg, he, err, true)
+	if encErr != nil {
+		if DefaultEncodeFailureHandler != nil {
+			DefaultEncodeFailureHandler.HandleEncodeFailure(err, encErr)
 		}
-		for k, v := range et.Headers() {
-			w.Header().Set(k, v)
+		body = DefaultResponseFormat.appendFallbackBody(body[:0], status, msg)
+	}
+	body = append(body, '\n')
+	*buf = body
+	hdrs.Set(hdrContentType, applicationJson)
+	hdrs.Set(hdrContentLength, strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	if r == nil || r.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+// bodyAllowed determines whether a response with the status may have a body
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#section-6.4.1
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// notModifiedHeaders are the headers that may be sent with a 304 Not Modified response
+//
+// see https://www.rfc-editor.org/rfc/rfc9110.html#name-304-not-modified
+var notModifiedHeaders = map[string]bool{
+	"Cache-Control":    true,
+	"Content-Location": true,
+	"Date":             true,
+	"Etag":             true,
+	"Expires":          true,
+	"Vary":             true,
+}
+
+func headerAllowed(status int, header string) bool {
+	return status != http.StatusNotModified || notModifiedHeaders[http.CanonicalHeaderKey(header)]
+}
+
+// errorHeaders returns the additional response headers for the error - all values if the error is a HeaderSetter,
+// otherwise the values from HttpError.Headers
+func errorHeaders(he HttpError) http.Header {
+	if he == nil {
+		return nil
+	} else if hs, ok := he.(HeaderSetter); ok {
+		return hs.Header()
+	}
+	hdrs := he.Headers()
+	result := make(http.Header, len(hdrs))
+	for k, v := range hdrs {
+		result.Set(k, v)
+	}
+	return result
+}
+
+// writeHeaders writes the error headers (as allowed by the DefaultHeaderPolicy), the DefaultCachePolicy caching headers
+// and then the DefaultHeaderPolicy default headers
+func writeHeaders(hdrs http.Header, status int, add http.Header) {
+	for k, vs := range add {
+		k = http.CanonicalHeaderKey(k)
+		if !headerAllowed(status, k) || DefaultHeaderPolicy.IsProtected(k) {
+			continue
 		}
-	case StatusError:
-		status = et.StatusCode()
-		if err.Error() != "" {
-			body[ptyError] = err.Error()
-		} else {
-			body[ptyError] = http.StatusText(status)
+		hdrs.Del(k)
+		for _, v := range vs {
+			if ValidHeader(k, v) {
+				hdrs.Add(k, v)
+			}
 		}
-	case error:
-		body[ptyError] = et.Error()
 	}
-	if DefaultErrorWriterShowCause {
-		if cause := errors.Unwrap(err); cause != nil {
-			body[ptyCause] = cause.Error()
+	DefaultCachePolicy.apply(hdrs, status, add)
+	DefaultHeaderPolicy.applyDefaults(hdrs, status)
+}
+
+func describeError(err error) (status int, msg string, he HttpError) {
+	if h, ok := err.(HttpError); ok {
+		return h.StatusCode(), h.Error(), h
+	}
+	return describeWrappedError(err)
+}
+
+func describeWrappedError(err error) (status int, msg string, he HttpError) {
+	var se StatusError
+	if errors.As(err, &he) {
+		return he.StatusCode(), he.Error(), he
+	} else if errors.As(err, &se) {
+		status = se.StatusCode()
+		if msg = se.Error(); msg == "" {
+			msg = http.StatusText(status)
 		}
+		return status, msg, nil
 	}
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(body)
+	status = http.StatusInternalServerError
+	if err == nil {
+		return status, http.StatusText(status), nil
+	}
+	if DefaultErrorStatusResolver != nil {
+		status = DefaultErrorStatusResolver.Resolve(err, status)
+	}
+	return status, err.Error(), nil
 }
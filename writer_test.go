@@ -1,6 +1,7 @@
 package httperr
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 )
 
@@ -46,7 +48,37 @@ func TestDefaultErrorWriter(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, body, 2)
 		require.Equal(t, "whoops", body[ptyError])
-		require.Equal(t, "something bad happened", body[ptyCause])
+		causes, ok := body[ptyCause].([]any)
+		require.True(t, ok)
+		require.Len(t, causes, 1)
+		require.Equal(t, map[string]any{"type": "*errors.errorString", "message": "something bad happened"}, causes[0])
+	})
+	t.Run("default (show cause chain)", func(t *testing.T) {
+		DefaultErrorWriterShowCause = true
+		defer func() {
+			DefaultErrorWriterShowCause = false
+		}()
+		w := httptest.NewRecorder()
+		inner := NewConflictError("conflict")
+		e := NewBadRequestError("whoops").WithCause(fmt.Errorf("wrapped: %w", inner))
+		DefaultErrorWriter.WriteError(e, w)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		causes, ok := body[ptyCause].([]any)
+		require.True(t, ok)
+		require.Len(t, causes, 1)
+		require.Equal(t, map[string]any{
+			"type":    "*fmt.wrapError",
+			"message": "wrapped: conflict",
+			"causes": []any{
+				map[string]any{
+					"type":    "*httperr.httpError",
+					"message": "conflict",
+					"status":  float64(http.StatusConflict),
+				},
+			},
+		}, causes[0])
 	})
 	t.Run("default (show stack)", func(t *testing.T) {
 		DefaultErrorWriterShowStack = true
@@ -126,6 +158,19 @@ func TestDefaultErrorWriter(t *testing.T) {
 		require.Equal(t, "bar", w.Result().Header.Get("foo"))
 		require.Equal(t, "baz", w.Result().Header.Get("bar"))
 	})
+	t.Run("default (with multi-valued headers)", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		w.Header().Set("Link", "<existing>")
+		e := NewUnauthorizedError("whoops").(HeaderSetter).
+			AppendHeader("WWW-Authenticate", `Basic realm="api"`).
+			AppendHeader("WWW-Authenticate", `Bearer realm="api"`).
+			AppendHeader("Link", "<a>").
+			AppendHeader("Link", "<b>")
+		DefaultErrorWriter.WriteError(e, w)
+		require.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+		require.Equal(t, []string{`Basic realm="api"`, `Bearer realm="api"`}, w.Result().Header.Values("WWW-Authenticate"))
+		require.Equal(t, []string{"<a>", "<b>"}, w.Result().Header.Values("Link"))
+	})
 	t.Run("StatusError", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		e := &testStatusError{"whoops", http.StatusTeapot}
@@ -156,6 +201,182 @@ func TestDefaultErrorWriter(t *testing.T) {
 		require.Len(t, body, 1)
 		require.Equal(t, "whoops", body[ptyError])
 	})
+	t.Run("wrapped HttpError", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		e := fmt.Errorf("middleware: %w", NewConflictError("whoops").AddHeader("X-Foo", "bar").AddReason("foo"))
+		DefaultErrorWriter.WriteError(e, w)
+		require.Equal(t, http.StatusConflict, w.Result().StatusCode)
+		require.Equal(t, "bar", w.Result().Header.Get("X-Foo"))
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Len(t, body, 2)
+		require.Equal(t, "whoops", body[ptyError])
+		require.Equal(t, []any{"foo"}, body[ptyReasons])
+	})
+	t.Run("wrapped StatusError", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		e := fmt.Errorf("middleware: %w", &testStatusError{"whoops", http.StatusTeapot})
+		DefaultErrorWriter.WriteError(e, w)
+		require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Len(t, body, 1)
+		require.Equal(t, "whoops", body[ptyError])
+	})
+	t.Run("plain error (with resolver)", func(t *testing.T) {
+		DefaultErrorStatusResolver = &testErrorStatusResolver{}
+		defer func() { DefaultErrorStatusResolver = nil }()
+		w := httptest.NewRecorder()
+		e := fmt.Errorf("lookup: %w", sql.ErrNoRows)
+		DefaultErrorWriter.WriteError(e, w)
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Len(t, body, 1)
+		require.Equal(t, e.Error(), body[ptyError])
+	})
+	t.Run("encode failure", func(t *testing.T) {
+		h := &testEncodeFailureHandler{}
+		DefaultEncodeFailureHandler = h
+		defer func() {
+			DefaultEncodeFailureHandler = nil
+		}()
+		w := httptest.NewRecorder()
+		e := NewBadRequestError("whoops").AddReason(make(chan int))
+		DefaultErrorWriter.WriteError(e, w)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		require.Equal(t, applicationJson, w.Header().Get("Content-Type"))
+		require.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{ptyError: "whoops"}, body)
+		require.Equal(t, e, h.err)
+		require.Error(t, h.encodeErr)
+	})
+	t.Run("encode failure (no handler, with envelope)", func(t *testing.T) {
+		DefaultResponseFormat = &ResponseFormat{Envelope: "error", IncludeStatus: true}
+		defer func() {
+			DefaultResponseFormat = &ResponseFormat{}
+		}()
+		w := httptest.NewRecorder()
+		e := NewBadRequestError("whoops").AddReason(func() {})
+		DefaultErrorWriter.WriteError(e, w)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		body, err := unmarshalBody(w.Result().Body)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"error": map[string]any{ptyError: "whoops", "$status": float64(http.StatusBadRequest)}}, body)
+	})
+	t.Run("content length", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		DefaultErrorWriter.WriteError(NewBadRequestError("whoops"), w)
+		require.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+	})
+}
+
+func TestDefaultErrorWriter_BodyRules(t *testing.T) {
+	ew := DefaultErrorWriter.(RequestErrorWriter)
+	t.Run("304 Not Modified", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		e := NewNotModifiedError("").AddHeaders(map[string]string{
+			"ETag":          `"abc"`,
+			"cache-control": "max-age=60",
+			"X-Foo":         "bar",
+		})
+		ew.WriteRequestError(e, w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusNotModified, w.Result().StatusCode)
+		require.Empty(t, w.Body.Bytes())
+		require.Equal(t, `"abc"`, w.Header().Get("ETag"))
+		require.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+		require.Empty(t, w.Header().Get("X-Foo"))
+		require.Empty(t, w.Header().Get("Content-Type"))
+		require.Empty(t, w.Header().Get("Content-Length"))
+	})
+	t.Run("204 No Content", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(New(http.StatusNoContent, "").AddHeader("X-Foo", "bar"), w, nil)
+		require.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+		require.Empty(t, w.Body.Bytes())
+		require.Equal(t, "bar", w.Header().Get("X-Foo"))
+		require.Empty(t, w.Header().Get("Content-Type"))
+	})
+	t.Run("1xx", func(t *testing.T) {
+		require.False(t, bodyAllowed(http.StatusContinue))
+		require.False(t, bodyAllowed(http.StatusEarlyHints))
+		require.True(t, bodyAllowed(http.StatusOK))
+		require.True(t, bodyAllowed(http.StatusBadRequest))
+	})
+	t.Run("HEAD", func(t *testing.T) {
+		get := httptest.NewRecorder()
+		ew.WriteRequestError(NewNotFoundError("whoops"), get, httptest.NewRequest(http.MethodGet, "/", nil))
+		w := httptest.NewRecorder()
+		ew.WriteRequestError(NewNotFoundError("whoops"), w, httptest.NewRequest(http.MethodHead, "/", nil))
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		require.Empty(t, w.Body.Bytes())
+		require.Equal(t, applicationJson, w.Header().Get("Content-Type"))
+		require.Equal(t, strconv.Itoa(get.Body.Len()), w.Header().Get("Content-Length"))
+	})
+}
+
+func BenchmarkDefaultErrorWriter(b *testing.B) {
+	w := &benchResponseWriter{header: http.Header{}}
+	b.Run("message", func(b *testing.B) {
+		e := NewBadRequestError("whoops")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			DefaultErrorWriter.WriteError(e, w)
+		}
+	})
+	b.Run("message and reasons", func(b *testing.B) {
+		e := NewBadRequestError("whoops").AddReasons("name is required", "age must be positive")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			DefaultErrorWriter.WriteError(e, w)
+		}
+	})
+	b.Run("struct reasons", func(b *testing.B) {
+		e := NewBadRequestError("whoops").AddReasons(testReason{"foo", "too big"}, testReason{"bar", "too small"})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			DefaultErrorWriter.WriteError(e, w)
+		}
+	})
+	b.Run("stack", func(b *testing.B) {
+		DefaultErrorWriterShowStack = true
+		defer func() {
+			DefaultErrorWriterShowStack = false
+		}()
+		e := NewBadRequestError("whoops")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			DefaultErrorWriter.WriteError(e, w)
+		}
+	})
+}
+
+type benchResponseWriter struct {
+	header http.Header
+}
+
+func (w *benchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *benchResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *benchResponseWriter) WriteHeader(int) {}
+
+type testEncodeFailureHandler struct {
+	err       error
+	encodeErr error
+}
+
+var _ EncodeFailureHandler = (*testEncodeFailureHandler)(nil)
+
+func (h *testEncodeFailureHandler) HandleEncodeFailure(err error, encodeErr error) {
+	h.err = err
+	h.encodeErr = encodeErr
 }
 
 type testReason struct {
@@ -183,3 +404,19 @@ func unmarshalBody(body io.ReadCloser) (map[string]any, error) {
 	err := json.NewDecoder(body).Decode(&result)
 	return result, err
 }
+
+// testForeignError is a HttpError implemented outside the package (only has the HttpError methods)
+type testForeignError struct {
+	HttpError
+}
+
+func TestDefaultErrorWriter_ForeignHttpError(t *testing.T) {
+	e := testForeignError{NewBadRequestError("whoops").AddHeader("X-Foo", "bar")}
+	_, ok := any(e).(HeaderSetter)
+	require.False(t, ok)
+	w := httptest.NewRecorder()
+	DefaultErrorWriter.WriteError(e, w)
+	require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	require.Equal(t, "bar", w.Header().Get("X-Foo"))
+	require.Contains(t, w.Body.String(), `"whoops"`)
+}